@@ -0,0 +1,309 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// arrayDataType is reported by the array types of this package, DataTypeOf
+// resolves it to `<element type>[]` using the field's size and precision.
+// Plain slices have no array encoding, they are mapped with serializer:array.
+const arrayDataType schema.DataType = "array"
+
+func init() {
+	schema.RegisterSerializer("array", ArraySerializer{})
+}
+
+// ArraySerializer stores plain slices, e.g. []int64 or []string, in array columns with the connector's
+// array encoding
+//
+//	type Post struct {
+//		Tags []string `gorm:"serializer:array"`
+//	}
+type ArraySerializer struct{}
+
+// Scan implements schema.SerializerInterface, NULL sets a nil slice
+func (ArraySerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		if err := pq.Array(fieldValue.Interface()).Scan(dbValue); err != nil {
+			return err
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (ArraySerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	return pq.Array(fieldValue).Value()
+}
+
+// StringArray maps to text[] (or varchar(n)[] with a size tag)
+type StringArray []string
+
+func (StringArray) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *StringArray) Scan(src interface{}) error {
+	return (*pq.StringArray)(a).Scan(src)
+}
+
+func (a StringArray) Value() (driver.Value, error) {
+	return pq.StringArray(a).Value()
+}
+
+// Int32Array maps to integer[]
+type Int32Array []int32
+
+func (Int32Array) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *Int32Array) Scan(src interface{}) error {
+	return (*pq.Int32Array)(a).Scan(src)
+}
+
+func (a Int32Array) Value() (driver.Value, error) {
+	return pq.Int32Array(a).Value()
+}
+
+// Int64Array maps to bigint[]
+type Int64Array []int64
+
+func (Int64Array) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *Int64Array) Scan(src interface{}) error {
+	return (*pq.Int64Array)(a).Scan(src)
+}
+
+func (a Int64Array) Value() (driver.Value, error) {
+	return pq.Int64Array(a).Value()
+}
+
+// NumericArray maps to numeric[] (or numeric(p, s)[] with precision and scale tags)
+type NumericArray []float64
+
+func (NumericArray) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *NumericArray) Scan(src interface{}) error {
+	return (*pq.Float64Array)(a).Scan(src)
+}
+
+func (a NumericArray) Value() (driver.Value, error) {
+	return pq.Float64Array(a).Value()
+}
+
+// BoolArray maps to boolean[]
+type BoolArray []bool
+
+func (BoolArray) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *BoolArray) Scan(src interface{}) error {
+	return (*pq.BoolArray)(a).Scan(src)
+}
+
+func (a BoolArray) Value() (driver.Value, error) {
+	return pq.BoolArray(a).Value()
+}
+
+// UUIDArray maps to uuid[], elements use the canonical textual form
+type UUIDArray []string
+
+func (UUIDArray) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *UUIDArray) Scan(src interface{}) error {
+	return (*pq.StringArray)(a).Scan(src)
+}
+
+func (a UUIDArray) Value() (driver.Value, error) {
+	return pq.StringArray(a).Value()
+}
+
+// TimeArray maps to timestamptz[]
+type TimeArray []time.Time
+
+// timestamptz output formats, the offset may or may not carry minutes
+var timeArrayLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+}
+
+func (TimeArray) GormDataType() string {
+	return string(arrayDataType)
+}
+
+func (a *TimeArray) Scan(src interface{}) error {
+	var elems pq.StringArray
+	if err := elems.Scan(src); err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	times := make(TimeArray, len(elems))
+	for idx, elem := range elems {
+		t, err := parseArrayTime(elem)
+		if err != nil {
+			return fmt.Errorf("pq: parsing array element index %d: %w", idx, err)
+		}
+		times[idx] = t
+	}
+	*a = times
+	return nil
+}
+
+func (a TimeArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	elems := make(pq.StringArray, len(a))
+	for idx, t := range a {
+		elems[idx] = t.Format(time.RFC3339Nano)
+	}
+	return elems.Value()
+}
+
+func parseArrayTime(s string) (t time.Time, err error) {
+	for _, layout := range timeArrayLayouts {
+		if t, err = time.Parse(layout, s); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// arrayElementTypes element data type and size of the array types in this package
+var arrayElementTypes = map[reflect.Type]struct {
+	DataType schema.DataType
+	Size     int
+}{
+	reflect.TypeOf(StringArray{}):  {DataType: schema.String},
+	reflect.TypeOf(Int32Array{}):   {DataType: schema.Int, Size: 32},
+	reflect.TypeOf(Int64Array{}):   {DataType: schema.Int, Size: 64},
+	reflect.TypeOf(NumericArray{}): {DataType: schema.Float},
+	reflect.TypeOf(BoolArray{}):    {DataType: schema.Bool},
+	reflect.TypeOf(UUIDArray{}):    {DataType: "uuid"},
+	reflect.TypeOf(TimeArray{}):    {DataType: schema.Time},
+}
+
+// arrayElementField returns a copy of an array field describing one element,
+// used to reuse the scalar mappings of DataTypeOf. The elements of plain slices
+// are only resolved for the fields of ArraySerializer, which encodes them.
+func arrayElementField(field *schema.Field) *schema.Field {
+	elem := *field
+	elem.AutoIncrement = false
+	elem.DataType = ""
+	elem.Serializer = nil
+
+	if elemType, ok := arrayElementTypes[field.IndirectFieldType]; ok {
+		elem.DataType = elemType.DataType
+		if elemType.Size > 0 {
+			elem.Size = elemType.Size
+		}
+		return &elem
+	}
+
+	if _, ok := field.Serializer.(ArraySerializer); !ok {
+		return &elem
+	}
+	if kind := field.IndirectFieldType.Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return &elem
+	}
+
+	switch elemType := field.IndirectFieldType.Elem(); elemType.Kind() {
+	case reflect.Bool:
+		elem.DataType = schema.Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		elem.DataType, elem.Size = schema.Int, elemType.Bits()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		elem.DataType, elem.Size = schema.Uint, elemType.Bits()
+	case reflect.Float32, reflect.Float64:
+		elem.DataType = schema.Float
+	case reflect.String:
+		elem.DataType = schema.String
+	default:
+		if elemType == reflect.TypeOf(time.Time{}) {
+			elem.DataType = schema.Time
+		}
+	}
+	return &elem
+}
+
+func (dialector Dialector) arrayDataTypeOf(field *schema.Field) string {
+	elem := arrayElementField(field)
+	if elem.DataType == "" {
+		if _, ok := field.Serializer.(ArraySerializer); !ok {
+			// a plain slice tagged type:array, without an encoding its values can't be stored
+			return string(field.DataType)
+		}
+		return "text[]"
+	}
+	return dialector.DataTypeOf(elem) + "[]"
+}
+
+// arrayValue wraps plain slices with the connector's array encoding
+func arrayValue(values interface{}) interface{} {
+	if _, ok := values.(driver.Valuer); ok {
+		return values
+	}
+	return pq.Array(values)
+}
+
+// ArrayContains column @> values, whether the array column contains every element of values
+//
+//	db.Where(postgres.ArrayContains{Column: "tags", Values: []string{"a", "b"}}).Find(&posts)
+type ArrayContains struct {
+	Column interface{}
+	Values interface{}
+}
+
+func (contains ArrayContains) Build(builder clause.Builder) {
+	builder.WriteQuoted(contains.Column)
+	builder.WriteString(" @> ")
+	builder.AddVar(builder, arrayValue(contains.Values))
+}
+
+// ArrayOverlap column && values, whether the array column shares any element with values
+type ArrayOverlap struct {
+	Column interface{}
+	Values interface{}
+}
+
+func (overlap ArrayOverlap) Build(builder clause.Builder) {
+	builder.WriteQuoted(overlap.Column)
+	builder.WriteString(" && ")
+	builder.AddVar(builder, arrayValue(overlap.Values))
+}
+
+// ArrayAny value = ANY(column), whether the array column contains value
+type ArrayAny struct {
+	Column interface{}
+	Value  interface{}
+}
+
+func (arrayAny ArrayAny) Build(builder clause.Builder) {
+	builder.AddVar(builder, arrayAny.Value)
+	builder.WriteString(" = ANY(")
+	builder.WriteQuoted(arrayAny.Column)
+	builder.WriteByte(')')
+}
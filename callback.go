@@ -68,6 +68,7 @@ func Update(config *callbacks.Config) func(db *gorm.DB) {
 				return
 			}
 
+			rewriteEmptyStringConditions(db)
 			db.Statement.Build(db.Statement.BuildClauses...)
 		}
 
@@ -75,7 +76,7 @@ func Update(config *callbacks.Config) func(db *gorm.DB) {
 
 		if !db.DryRun && db.Error == nil {
 			if ok, mode := hasReturning(db, supportReturning); ok {
-				sql := ConvertMysqlSql(db.Statement.SQL.String())
+				sql := convertSQL(db, db.Statement.SQL.String())
 				if rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, sql, db.Statement.Vars...); db.AddError(err) == nil {
 					dest := db.Statement.Dest
 					db.Statement.Dest = db.Statement.ReflectValue.Addr().Interface()
@@ -84,7 +85,7 @@ func Update(config *callbacks.Config) func(db *gorm.DB) {
 					db.AddError(rows.Close())
 				}
 			} else {
-				sql := ConvertMysqlSql(db.Statement.SQL.String())
+				sql := convertSQL(db, db.Statement.SQL.String())
 				result, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, sql, db.Statement.Vars...)
 
 				if db.AddError(err) == nil {
@@ -133,6 +134,7 @@ func Delete(config *callbacks.Config) func(db *gorm.DB) {
 
 			db.Statement.AddClauseIfNotExists(clause.From{})
 
+			rewriteEmptyStringConditions(db)
 			db.Statement.Build(db.Statement.BuildClauses...)
 		}
 
@@ -141,7 +143,7 @@ func Delete(config *callbacks.Config) func(db *gorm.DB) {
 		if !db.DryRun && db.Error == nil {
 			ok, mode := hasReturning(db, supportReturning)
 			if !ok {
-				sql := ConvertMysqlSql(db.Statement.SQL.String())
+				sql := convertSQL(db, db.Statement.SQL.String())
 				result, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, sql, db.Statement.Vars...)
 				if db.AddError(err) == nil {
 					db.RowsAffected, _ = result.RowsAffected()
@@ -150,7 +152,7 @@ func Delete(config *callbacks.Config) func(db *gorm.DB) {
 				return
 			}
 
-			sql := ConvertMysqlSql(db.Statement.SQL.String())
+			sql := convertSQL(db, db.Statement.SQL.String())
 			if rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, sql, db.Statement.Vars...); db.AddError(err) == nil {
 				gorm.Scan(rows, db, mode)
 				db.AddError(rows.Close())
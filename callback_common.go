@@ -8,17 +8,59 @@ import (
 )
 
 // ConvertMysqlSql 兼容mysql的sql
-// 将 ` 转为 "
+// 将 ` 转为 ", 字符串常量('...', E'...')与双引号标识符中的 ` 保持不变
 func ConvertMysqlSql(sql string) string {
-	// 替换 `` 符号
-	sql = strings.ReplaceAll(sql, "`", "\"")
-	return sql
+	if !strings.Contains(sql, "`") {
+		return sql
+	}
+
+	var (
+		builder strings.Builder
+		quote   byte // 当前所在的引号, 0 表示不在引号中
+		escape  bool // E'...' 字符串中 \ 转义下一个字符
+	)
+	builder.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote == 0 && c == '`':
+			c = '"'
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+			escape = c == '\'' && i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e')
+		case quote == '\'' && escape && c == '\\':
+			builder.WriteByte(c)
+			if i++; i < len(sql) {
+				c = sql[i]
+			} else {
+				continue
+			}
+		case c == quote:
+			// '' 与 "" 是引号中的转义, 仍在引号中
+			if i+1 < len(sql) && sql[i+1] == quote {
+				builder.WriteByte(c)
+				i++
+			} else {
+				quote = 0
+			}
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// convertSQL 转换mysql的sql, B模式(DBCOMPATIBILITY=B)的数据库原生支持 ` 标识符, 保持不变
+func convertSQL(db *gorm.DB, sql string) string {
+	if dialectorOf(db).compatibility() == CompatibilityB {
+		return sql
+	}
+	return ConvertMysqlSql(sql)
 }
 
 func BuildQuerySQL(db *gorm.DB) {
 	buildQuerySQL(db)
 	sql := db.Statement.SQL.String()
-	sql = ConvertMysqlSql(sql)
+	sql = convertSQL(db, sql)
 	db.Statement.SQL.Reset()
 	db.Statement.SQL.WriteString(sql)
 }
@@ -48,14 +90,19 @@ func buildQuerySQL(db *gorm.DB) {
 		}
 
 		if len(db.Statement.Selects) > 0 {
-			clauseSelect.Columns = make([]clause.Column, len(db.Statement.Selects))
-			for idx, name := range db.Statement.Selects {
-				if db.Statement.Schema == nil {
-					clauseSelect.Columns[idx] = clause.Column{Name: name, Raw: true}
-				} else if f := db.Statement.Schema.LookUpField(name); f != nil {
-					clauseSelect.Columns[idx] = clause.Column{Name: f.DBName}
+			clauseSelect.Columns = make([]clause.Column, 0, len(db.Statement.Selects))
+			for _, name := range db.Statement.Selects {
+				if db.Statement.Schema != nil {
+					if f := db.Statement.Schema.LookUpField(name); f != nil {
+						clauseSelect.Columns = append(clauseSelect.Columns, clause.Column{Name: f.DBName})
+						continue
+					}
+				}
+				// 原始的select字段, 对关键字命名的字段加引号
+				if columns, ok := dialectorOf(db).reservedSelectColumns(name); ok {
+					clauseSelect.Columns = append(clauseSelect.Columns, columns...)
 				} else {
-					clauseSelect.Columns[idx] = clause.Column{Name: name, Raw: true}
+					clauseSelect.Columns = append(clauseSelect.Columns, clause.Column{Name: name, Raw: true})
 				}
 			}
 		} else if db.Statement.Schema != nil && len(db.Statement.Omits) > 0 {
@@ -63,7 +110,7 @@ func buildQuerySQL(db *gorm.DB) {
 			clauseSelect.Columns = make([]clause.Column, 0, len(db.Statement.Schema.DBNames))
 			for _, dbName := range db.Statement.Schema.DBNames {
 				if v, ok := selectColumns[dbName]; (ok && v) || !ok {
-					clauseSelect.Columns = append(clauseSelect.Columns, newColumn(db.Statement.Table, dbName))
+					clauseSelect.Columns = append(clauseSelect.Columns, clause.Column{Table: db.Statement.Table, Name: dbName})
 				}
 			}
 		} else if db.Statement.Schema != nil && db.Statement.ReflectValue.IsValid() {
@@ -84,7 +131,7 @@ func buildQuerySQL(db *gorm.DB) {
 					clauseSelect.Columns = make([]clause.Column, len(stmt.Schema.DBNames))
 
 					for idx, dbName := range stmt.Schema.DBNames {
-						clauseSelect.Columns[idx] = newColumn(db.Statement.Table, dbName)
+						clauseSelect.Columns[idx] = clause.Column{Table: db.Statement.Table, Name: dbName}
 					}
 				}
 			}
@@ -100,7 +147,7 @@ func buildQuerySQL(db *gorm.DB) {
 			if len(db.Statement.Selects) == 0 && len(db.Statement.Omits) == 0 && db.Statement.Schema != nil {
 				clauseSelect.Columns = make([]clause.Column, len(db.Statement.Schema.DBNames))
 				for idx, dbName := range db.Statement.Schema.DBNames {
-					clauseSelect.Columns[idx] = newColumn(db.Statement.Table, dbName)
+					clauseSelect.Columns[idx] = clause.Column{Table: db.Statement.Table, Name: dbName}
 				}
 			}
 
@@ -191,19 +238,11 @@ func buildQuerySQL(db *gorm.DB) {
 
 		db.Statement.AddClauseIfNotExists(clauseSelect)
 
+		rewriteEmptyStringConditions(db)
 		db.Statement.Build(db.Statement.BuildClauses...)
 	}
 }
 
-func newColumn(table, name string) clause.Column {
-	cc := clause.Column{Table: table, Name: name}
-	//if IsReservedWord(name) {
-	//	// 针对关键字进行as
-	//	cc.Alias = fmt.Sprintf(`"%s"`, name)
-	//}
-	return cc
-}
-
 func checkMissingWhereConditions(db *gorm.DB) {
 	if !db.AllowGlobalUpdate && db.Error == nil {
 		where, withCondition := db.Statement.Clauses["WHERE"]
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DBCOMPATIBILITY modes of an openGauss database
+const (
+	CompatibilityA  = "A"  // Oracle
+	CompatibilityB  = "B"  // MySQL
+	CompatibilityC  = "C"  // Teradata
+	CompatibilityPG = "PG" // PostgreSQL
+)
+
+// compatibilityTypeAliasMap type aliases that only apply to one compatibility mode
+var compatibilityTypeAliasMap = map[string]map[string][]string{
+	CompatibilityB: {
+		"int4":  {"int"},
+		"uint1": {"tinyint unsigned"},
+		"uint2": {"smallint unsigned"},
+		"uint4": {"int unsigned"},
+		"uint8": {"bigint unsigned"},
+	},
+	CompatibilityA: {
+		"varchar":  {"varchar2"},
+		"varchar2": {"varchar"},
+		"numeric":  {"number", "decimal"},
+		"text":     {"clob"},
+		"clob":     {"text"},
+		"bytea":    {"blob", "raw"},
+		"blob":     {"bytea"},
+	},
+}
+
+func (dialector Dialector) compatibility() string {
+	if dialector.Config == nil {
+		return ""
+	}
+	return dialector.Config.Compatibility
+}
+
+// dialectorOf returns the openGauss dialector of db
+func dialectorOf(db *gorm.DB) Dialector {
+	switch dialector := db.Dialector.(type) {
+	case *Dialector:
+		return *dialector
+	}
+	return Dialector{}
+}
+
+// oracleDataTypeOf data types of DBCOMPATIBILITY=A databases, where DATE carries time
+// and empty strings are stored as NULL.
+func (dialector Dialector) oracleDataTypeOf(field *schema.Field) (string, bool) {
+	switch field.DataType {
+	case schema.Float:
+		if field.Precision > 0 {
+			if field.Scale > 0 {
+				return fmt.Sprintf("number(%d, %d)", field.Precision, field.Scale), true
+			}
+			return fmt.Sprintf("number(%d)", field.Precision), true
+		}
+		return "number", true
+	case schema.String:
+		if field.Size > 0 {
+			return fmt.Sprintf("varchar2(%d)", field.Size), true
+		}
+		return "clob", true
+	case schema.Bytes:
+		if field.Size > 0 && field.Size <= 2000 {
+			return fmt.Sprintf("raw(%d)", field.Size), true
+		}
+		return "blob", true
+	}
+	return "", false
+}
+
+// mysqlDataTypeOf data types of DBCOMPATIBILITY=B databases, which support unsigned integers
+// and AUTO_INCREMENT natively.
+func (dialector Dialector) mysqlDataTypeOf(field *schema.Field) (string, bool) {
+	var sqlType string
+	switch field.DataType {
+	case schema.Int, schema.Uint:
+		switch {
+		case field.DataType == schema.Uint && field.Size <= 8:
+			sqlType = "tinyint"
+		case field.Size <= 16:
+			sqlType = "smallint"
+		case field.Size <= 32:
+			sqlType = "int"
+		default:
+			sqlType = "bigint"
+		}
+		if field.DataType == schema.Uint {
+			sqlType += " unsigned"
+		}
+	default:
+		return "", false
+	}
+
+	if field.AutoIncrement {
+		sqlType += " AUTO_INCREMENT"
+	}
+	return sqlType, true
+}
+
+// isEmptyStringDefault whether the default value of field is an empty string,
+// which DBCOMPATIBILITY=A databases store as NULL
+func isEmptyStringDefault(field *schema.Field) bool {
+	if !field.HasDefaultValue {
+		return false
+	}
+	if s, ok := field.DefaultValueInterface.(string); ok {
+		return s == ""
+	}
+	return field.DefaultValue == "''"
+}
+
+// rewriteEmptyStringConditions rewrites equality with an empty string to IS NULL on
+// DBCOMPATIBILITY=A databases, where an empty string never equals anything. Raw SQL conditions
+// are rewritten when they are a single comparison, e.g. Where("name = ?", "").
+func rewriteEmptyStringConditions(db *gorm.DB) {
+	if dialectorOf(db).compatibility() != CompatibilityA {
+		return
+	}
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			where.Exprs = rewriteEmptyStringExprs(where.Exprs)
+			c.Expression = where
+			db.Statement.Clauses["WHERE"] = c
+		}
+	}
+}
+
+// emptyStringConditionMatcher matches raw SQL conditions comparing a column with a single value, e.g. name = ?
+var emptyStringConditionMatcher = regexp.MustCompile(`^\s*([\w."]+)\s*(=|<>|!=)\s*\?\s*$`)
+
+func rewriteEmptyStringExprs(exprs []clause.Expression) []clause.Expression {
+	results := make([]clause.Expression, len(exprs))
+	for idx, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Eq:
+			if s, ok := e.Value.(string); ok && s == "" {
+				expr = clause.Eq{Column: e.Column, Value: nil}
+			}
+		case clause.Neq:
+			if s, ok := e.Value.(string); ok && s == "" {
+				expr = clause.Neq{Column: e.Column, Value: nil}
+			}
+		case clause.Expr:
+			if len(e.Vars) == 1 {
+				if s, ok := e.Vars[0].(string); ok && s == "" {
+					if matches := emptyStringConditionMatcher.FindStringSubmatch(e.SQL); matches != nil {
+						if matches[2] == "=" {
+							expr = clause.Expr{SQL: matches[1] + " IS NULL"}
+						} else {
+							expr = clause.Expr{SQL: matches[1] + " IS NOT NULL"}
+						}
+					}
+				}
+			}
+		case clause.AndConditions:
+			expr = clause.AndConditions{Exprs: rewriteEmptyStringExprs(e.Exprs)}
+		case clause.OrConditions:
+			expr = clause.OrConditions{Exprs: rewriteEmptyStringExprs(e.Exprs)}
+		case clause.NotConditions:
+			expr = clause.NotConditions{Exprs: rewriteEmptyStringExprs(e.Exprs)}
+		case clause.Where:
+			expr = clause.Where{Exprs: rewriteEmptyStringExprs(e.Exprs)}
+		}
+		results[idx] = expr
+	}
+	return results
+}
@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+)
+
+// PasswordProvider returns the password of a new connection, e.g. a rotated one from a secrets manager
+type PasswordProvider func(ctx context.Context) (string, error)
+
+// ValidateConnectFunc validates a new connection before it joins the pool,
+// an error closes the connection and the next host of Fallbacks is tried
+type ValidateConnectFunc func(ctx context.Context, conn *SessionConn) error
+
+// AfterConnectFunc sets up a new validated connection, e.g. SET search_path, an error fails the connection attempt
+type AfterConnectFunc func(ctx context.Context, conn *SessionConn) error
+
+// connector opens each physical connection with a fresh copy of the parsed config,
+// applying the Config hooks the connector of the driver doesn't know about
+type connector struct {
+	config           *pq.Config
+	driver           driver.Driver
+	passwordProvider PasswordProvider
+	validateConnect  ValidateConnectFunc
+	afterConnect     AfterConnectFunc
+	onNotice         NoticeHandler
+	onNotification   func(Notification)
+	detectFailover   bool
+	failoverInterval time.Duration
+	connect          func(ctx context.Context, config *pq.Config) (driver.Conn, error)
+}
+
+// newConnector the connector of pool, hooks of config are applied to every new connection
+func newConnector(pqConfig *pq.Config, config *Config) (driver.Connector, error) {
+	base, err := pq.NewConnectorConfig(pqConfig)
+	if err != nil {
+		return nil, err
+	}
+	if config.PasswordProvider == nil && config.ValidateConnect == nil && config.AfterConnect == nil &&
+		config.OnNotice == nil && config.OnNotification == nil && !config.DetectFailover {
+		return base, nil
+	}
+
+	return &connector{
+		config:           pqConfig,
+		driver:           base.Driver(),
+		passwordProvider: config.PasswordProvider,
+		validateConnect:  config.ValidateConnect,
+		afterConnect:     config.AfterConnect,
+		onNotice:         config.OnNotice,
+		onNotification:   config.OnNotification,
+		detectFailover:   config.DetectFailover,
+		failoverInterval: config.FailoverCheckInterval,
+		connect:          connectConfig,
+	}, nil
+}
+
+// connectConfig opens a connection with the connector of the driver
+func connectConfig(ctx context.Context, config *pq.Config) (driver.Conn, error) {
+	pqConnector, err := pq.NewConnectorConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return pqConnector.Connect(ctx)
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	config := *c.config
+	if c.passwordProvider != nil {
+		password, err := c.passwordProvider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+		config.Password = password
+	}
+
+	if c.validateConnect == nil {
+		conn, err := c.connect(ctx, &config)
+		if err != nil {
+			return nil, err
+		}
+		return c.setup(ctx, conn)
+	}
+
+	// the hosts are tried one by one, the driver can't validate them
+	hosts := append([]*pq.FallbackConfig{{Host: config.Host, Port: config.Port, TLSConfig: config.TLSConfig}}, config.Fallbacks...)
+	var err error
+	for _, host := range hosts {
+		hostConfig := config
+		hostConfig.Host, hostConfig.Port, hostConfig.TLSConfig, hostConfig.Fallbacks = host.Host, host.Port, host.TLSConfig, nil
+
+		var conn driver.Conn
+		if conn, err = c.connect(ctx, &hostConfig); err == nil {
+			if err = c.validateConnect(ctx, &SessionConn{conn: conn}); err == nil {
+				return c.setup(ctx, conn)
+			}
+			conn.Close()
+		}
+		err = fmt.Errorf("failed to connect to %s:%d: %w", host.Host, host.Port, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// setup sets the notification and notice handlers and runs AfterConnect on the validated conn
+func (c *connector) setup(ctx context.Context, conn driver.Conn) (driver.Conn, error) {
+	if c.onNotification != nil {
+		pq.SetNotificationHandler(conn, func(n *pq.Notification) {
+			c.onNotification(notificationOf(n))
+		})
+	}
+	if c.onNotice != nil {
+		conn = newNoticeConn(conn, c.onNotice)
+	}
+	if c.afterConnect != nil {
+		if err := c.afterConnect(ctx, &SessionConn{conn: conn}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.detectFailover {
+		return newFailoverConn(conn, c.failoverInterval), nil
+	}
+	return conn, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// SessionConn a new physical connection, not yet in the pool
+type SessionConn struct {
+	conn driver.Conn
+}
+
+// Exec executes query on the connection
+func (c *SessionConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return errors.New("connection doesn't support Exec")
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return err
+	}
+	_, err = execer.ExecContext(ctx, query, values)
+	return err
+}
+
+// QueryRow queries a single row on the connection, Scan of the result reads its values
+func (c *SessionConn) QueryRow(ctx context.Context, query string, args ...interface{}) *SessionRow {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return &SessionRow{err: errors.New("connection doesn't support Query")}
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return &SessionRow{err: err}
+	}
+
+	rows, err := queryer.QueryContext(ctx, query, values)
+	if err != nil {
+		return &SessionRow{err: err}
+	}
+	defer rows.Close()
+
+	row := &SessionRow{values: make([]driver.Value, len(rows.Columns()))}
+	if err := rows.Next(row.values); err != nil {
+		if err == io.EOF {
+			err = sql.ErrNoRows
+		}
+		row.err = err
+	}
+	return row
+}
+
+func namedValues(args []interface{}) ([]driver.NamedValue, error) {
+	values := make([]driver.NamedValue, len(args))
+	for idx, arg := range args {
+		value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[idx] = driver.NamedValue{Ordinal: idx + 1, Value: value}
+	}
+	return values, nil
+}
+
+// SessionRow the result of SessionConn.QueryRow
+type SessionRow struct {
+	values []driver.Value
+	err    error
+}
+
+// Scan copies the values of the row into dest, which are pointers to basic types or sql.Scanner
+func (row *SessionRow) Scan(dest ...interface{}) error {
+	if row.err != nil {
+		return row.err
+	}
+	if len(dest) != len(row.values) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(row.values), len(dest))
+	}
+
+	for idx, value := range row.values {
+		if err := scanValue(dest[idx], value); err != nil {
+			return fmt.Errorf("converting column %d: %w", idx, err)
+		}
+	}
+	return nil
+}
+
+// scanValue converts value into dest with the conversions of the sql.Null types
+func scanValue(dest interface{}, value driver.Value) error {
+	switch d := dest.(type) {
+	case sql.Scanner:
+		return d.Scan(value)
+	case *interface{}:
+		*d = value
+	case *[]byte:
+		switch v := value.(type) {
+		case nil:
+			*d = nil
+		case []byte:
+			*d = append([]byte(nil), v...)
+		case string:
+			*d = []byte(v)
+		default:
+			*d = []byte(fmt.Sprint(v))
+		}
+	case *string:
+		var v sql.NullString
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.String
+	case *bool:
+		var v sql.NullBool
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Bool
+	case *int, *int64:
+		var v sql.NullInt64
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		if p, ok := d.(*int); ok {
+			*p = int(v.Int64)
+		} else {
+			*d.(*int64) = v.Int64
+		}
+	case *float64:
+		var v sql.NullFloat64
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Float64
+	case *time.Time:
+		var v sql.NullTime
+		if err := v.Scan(value); err != nil {
+			return err
+		}
+		*d = v.Time
+	default:
+		return fmt.Errorf("unsupported Scan destination %T", dest)
+	}
+	return nil
+}
+
+// ValidatePrimary rejects standby servers, to connect to the primary of Fallbacks
+func ValidatePrimary(ctx context.Context, conn *SessionConn) error {
+	var inRecovery bool
+	if err := conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return err
+	}
+	if inRecovery {
+		return errors.New("server is a standby")
+	}
+	return nil
+}
+
+// ValidateServerVersion rejects servers older than major.minor.patch or of an unknown version
+func ValidateServerVersion(major, minor, patch int) ValidateConnectFunc {
+	return func(ctx context.Context, conn *SessionConn) error {
+		var version string
+		if err := conn.QueryRow(ctx, "SELECT version()").Scan(&version); err != nil {
+			return err
+		}
+		info := parseServerVersion(version)
+		if !info.Known() {
+			return fmt.Errorf("server version %q is unknown", info.Version)
+		}
+		if !info.AtLeast(major, minor, patch) {
+			return fmt.Errorf("server version %q is older than %d.%d.%d", info.Version, major, minor, patch)
+		}
+		return nil
+	}
+}
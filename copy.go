@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// RowSource the rows loaded by CopyFrom, Values returns the values of the current row in the order of the columns
+type RowSource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+// CopyFromRows a RowSource of rows
+func CopyFromRows(rows [][]interface{}) RowSource {
+	return &sliceRows{rows: rows, idx: -1}
+}
+
+type sliceRows struct {
+	rows [][]interface{}
+	idx  int
+}
+
+func (r *sliceRows) Next() bool {
+	r.idx++
+	return r.idx < len(r.rows)
+}
+
+func (r *sliceRows) Values() ([]interface{}, error) {
+	return r.rows[r.idx], nil
+}
+
+func (r *sliceRows) Err() error {
+	return nil
+}
+
+// modelRows a RowSource of a slice of models
+type modelRows struct {
+	ctx    context.Context
+	rows   reflect.Value
+	fields []*schema.Field
+	idx    int
+}
+
+func (r *modelRows) Next() bool {
+	r.idx++
+	return r.idx < r.rows.Len()
+}
+
+func (r *modelRows) Values() ([]interface{}, error) {
+	row := reflect.Indirect(r.rows.Index(r.idx))
+	if row.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported row %s, expected a struct", row.Type())
+	}
+	values := make([]interface{}, len(r.fields))
+	for idx, field := range r.fields {
+		values[idx], _ = field.ValueOf(r.ctx, row)
+	}
+	return values, nil
+}
+
+func (r *modelRows) Err() error {
+	return nil
+}
+
+// CopyFrom loads rows into table with COPY FROM STDIN, in a transaction or the current transaction of db,
+// and returns the number of rows loaded.
+//
+// table is a table name or a model, rows a slice of models or a RowSource. The columns of models are their
+// creatable fields except the auto increment ones, columns selects some of them by name or column name.
+// Rows of a RowSource into a table name require columns.
+// The rows are streamed, the connector sends them in chunks of CpBufferSize bytes.
+//
+//	count, err := postgres.CopyFrom(ctx, db, &User{}, users)
+//	count, err := postgres.CopyFrom(ctx, db, "users", postgres.CopyFromRows(rows), "name", "age")
+func CopyFrom(ctx context.Context, db *gorm.DB, table interface{}, rows interface{}, columns ...string) (int64, error) {
+	tx := db.WithContext(ctx)
+	stmt := &gorm.Statement{DB: tx, Context: ctx}
+
+	name, isName := table.(string)
+	if !isName {
+		if err := stmt.Parse(table); err != nil {
+			return 0, err
+		}
+	}
+	source, isSource := rows.(RowSource)
+	if !isSource && stmt.Schema == nil {
+		if err := stmt.Parse(rows); err != nil {
+			return 0, err
+		}
+	}
+	if isName {
+		stmt.Table = name
+	}
+
+	var fields []*schema.Field
+	if stmt.Schema != nil {
+		var err error
+		if fields, err = copyFields(stmt.Schema, columns); err != nil {
+			return 0, err
+		}
+		columns = make([]string, len(fields))
+		for idx, field := range fields {
+			columns[idx] = field.DBName
+		}
+	} else if len(columns) == 0 {
+		return 0, fmt.Errorf("columns are required to copy rows into %s", stmt.Table)
+	}
+
+	if !isSource {
+		value := reflect.Indirect(reflect.ValueOf(rows))
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return 0, fmt.Errorf("unsupported rows %T, expected a slice of models or a RowSource", rows)
+		}
+		source = &modelRows{ctx: ctx, rows: value, fields: fields, idx: -1}
+	}
+
+	stmt.WriteString("COPY ")
+	stmt.WriteQuoted(clause.Table{Name: stmt.Table})
+	stmt.WriteString(" (")
+	for idx, column := range columns {
+		if idx > 0 {
+			stmt.WriteByte(',')
+		}
+		stmt.WriteQuoted(column)
+	}
+	stmt.WriteString(") FROM STDIN")
+
+	var loaded int64
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		copyStmt, err := tx.Statement.ConnPool.PrepareContext(ctx, stmt.SQL.String())
+		if err != nil {
+			return err
+		}
+		defer copyStmt.Close()
+
+		for source.Next() {
+			values, err := source.Values()
+			if err != nil {
+				return err
+			}
+			if _, err := copyStmt.ExecContext(ctx, values...); err != nil {
+				return err
+			}
+		}
+		if err := source.Err(); err != nil {
+			return err
+		}
+
+		// an Exec without values flushes the buffered rows and ends the COPY
+		result, err := copyStmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		loaded, err = result.RowsAffected()
+		return err
+	})
+	return loaded, err
+}
+
+// copyFields the fields of columns, the creatable fields except the auto increment ones by default
+func copyFields(s *schema.Schema, columns []string) ([]*schema.Field, error) {
+	var fields []*schema.Field
+	if len(columns) == 0 {
+		for _, field := range s.Fields {
+			if field.DBName != "" && field.Creatable && !field.AutoIncrement {
+				fields = append(fields, field)
+			}
+		}
+		return fields, nil
+	}
+
+	for _, column := range columns {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("unknown column %s of %s", column, s.Name)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// cursorSeq numbers the cursors of FindInCursor, their names are unique in a session
+var cursorSeq uint64
+
+// FindInCursor finds the records of db into dest in batches of batchSize with a server-side cursor, fc is called
+// for each batch. Unlike FindInBatches the query isn't paginated by primary key, any query can be read with bounded
+// memory, in a transaction that lasts until the last batch. An error of fc stops the iteration.
+//
+// The batches are found with the columns of the query, the AfterFind hooks of the records run for each batch,
+// Preload isn't supported.
+//
+//	result := postgres.FindInCursor(db.Where("processed = ?", false), &results, 1000, func(tx *gorm.DB, batch int) error {
+//		for _, result := range results {
+//			// process each record
+//		}
+//		return nil
+//	})
+func FindInCursor(db *gorm.DB, dest interface{}, batchSize int, fc func(tx *gorm.DB, batch int) error) *gorm.DB {
+	result := db.Session(&gorm.Session{})
+	if batchSize <= 0 {
+		result.AddError(fmt.Errorf("invalid batch size %d", batchSize))
+		return result
+	}
+
+	dryRun := db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true}).Find(dest)
+	if dryRun.Error != nil {
+		result.AddError(dryRun.Error)
+		return result
+	}
+	stmt := dryRun.Statement
+	if db.DryRun {
+		result.Statement = stmt
+		return result
+	}
+
+	name := fmt.Sprintf("gorm_cursor_%d", atomic.AddUint64(&cursorSeq, 1))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ctx := tx.Statement.Context
+		if _, err := tx.Statement.ConnPool.ExecContext(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+stmt.SQL.String(), stmt.Vars...); err != nil {
+			return err
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", batchSize, name)
+		for batch := 1; ; batch++ {
+			// Find runs the query callbacks and their AfterFind hooks, unlike Scan
+			rows := tx.Raw(fetch).Find(dest)
+			if rows.Error != nil {
+				return rows.Error
+			}
+			result.RowsAffected += rows.RowsAffected
+			if rows.RowsAffected == 0 {
+				break
+			}
+			if err := fc(rows, batch); err != nil {
+				return err
+			}
+			if rows.RowsAffected < int64(batchSize) {
+				break
+			}
+		}
+		return tx.Exec("CLOSE " + name).Error
+	})
+	result.AddError(err)
+	return result
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// errExplainRollback rolls back the statements run by EXPLAIN ANALYZE
+var errExplainRollback = errors.New("rollback explained statement")
+
+// ExplainOptions the options of EXPLAIN, the plan is always in the JSON format
+type ExplainOptions struct {
+	Analyze bool // runs the statement for the actual times and rows, in a transaction rolled back afterwards
+	Buffers bool // buffer usage, requires Analyze
+	Verbose bool
+}
+
+// Plan the plan of an explained statement
+type Plan struct {
+	Root          *PlanNode
+	PlanningTime  float64 // in milliseconds, with Analyze
+	ExecutionTime float64 // in milliseconds, with Analyze
+}
+
+func (plan *Plan) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Plan          *PlanNode `json:"Plan"`
+		PlanningTime  float64   `json:"Planning Time"`
+		ExecutionTime float64   `json:"Execution Time"`
+		TotalRuntime  float64   `json:"Total Runtime"` // openGauss name of Execution Time
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	plan.Root, plan.PlanningTime, plan.ExecutionTime = raw.Plan, raw.PlanningTime, raw.ExecutionTime
+	if plan.ExecutionTime == 0 {
+		plan.ExecutionTime = raw.TotalRuntime
+	}
+	return nil
+}
+
+// PlanNode a node of a plan, the Actual fields are set with Analyze and the block counts with Buffers
+type PlanNode struct {
+	NodeType          string  `json:"Node Type"`
+	RelationName      string  `json:"Relation Name"`
+	Alias             string  `json:"Alias"`
+	IndexName         string  `json:"Index Name"`
+	StartupCost       float64 `json:"Startup Cost"`
+	TotalCost         float64 `json:"Total Cost"`
+	PlanRows          float64 `json:"Plan Rows"`
+	PlanWidth         int     `json:"Plan Width"`
+	ActualStartupTime float64 `json:"Actual Startup Time"`
+	ActualTotalTime   float64 `json:"Actual Total Time"`
+	ActualRows        float64 `json:"Actual Rows"`
+	ActualLoops       float64 `json:"Actual Loops"`
+	SharedHitBlocks   int64   `json:"Shared Hit Blocks"`
+	SharedReadBlocks  int64   `json:"Shared Read Blocks"`
+	Plans             []*PlanNode
+
+	// Properties all the properties of the node, including the ones above, e.g. Filter or Join Type
+	Properties map[string]interface{} `json:"-"`
+}
+
+func (node *PlanNode) UnmarshalJSON(data []byte) error {
+	type planNode PlanNode
+	if err := json.Unmarshal(data, (*planNode)(node)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &node.Properties)
+}
+
+// Walk calls fc for node and its children, depth first
+func (node *PlanNode) Walk(fc func(node *PlanNode)) {
+	fc(node)
+	for _, child := range node.Plans {
+		child.Walk(fc)
+	}
+}
+
+// ExplainQuery explains the statement of db and returns its plan. The statement is the SELECT of the conditions of db,
+// or the statement db built in a DryRun session, e.g. an Update.
+//
+//	plan, err := postgres.ExplainQuery(db.Model(&User{}).Where("name = ?", "jinzhu"), postgres.ExplainOptions{Analyze: true})
+//	plan.Root.Walk(func(node *postgres.PlanNode) {
+//		if node.NodeType == "Seq Scan" {
+//			...
+//		}
+//	})
+func ExplainQuery(db *gorm.DB, opts ExplainOptions) (*Plan, error) {
+	stmt := db.Statement
+	if stmt.SQL.Len() == 0 {
+		dryRun := db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true}).Find(&[]map[string]interface{}{})
+		if dryRun.Error != nil {
+			return nil, dryRun.Error
+		}
+		stmt = dryRun.Statement
+	}
+
+	options := []string{"FORMAT JSON"}
+	if opts.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	if opts.Buffers {
+		options = append(options, "BUFFERS")
+	}
+	if opts.Verbose {
+		options = append(options, "VERBOSE")
+	}
+	query := "EXPLAIN (" + strings.Join(options, ", ") + ") " + stmt.SQL.String()
+
+	var output strings.Builder
+	explain := func(tx *gorm.DB) error {
+		rows, err := tx.Statement.ConnPool.QueryContext(stmt.Context, query, stmt.Vars...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				return err
+			}
+			output.WriteString(line)
+		}
+		return rows.Err()
+	}
+
+	var err error
+	if opts.Analyze {
+		// ANALYZE runs the statement, its changes are rolled back
+		if err = db.Session(&gorm.Session{NewDB: true}).Transaction(func(tx *gorm.DB) error {
+			if err := explain(tx); err != nil {
+				return err
+			}
+			return errExplainRollback
+		}); errors.Is(err, errExplainRollback) {
+			err = nil
+		}
+	} else {
+		err = explain(db)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []*Plan
+	if err := json.Unmarshal([]byte(output.String()), &plans); err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, errors.New("empty plan")
+	}
+	return plans[0], nil
+}
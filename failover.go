@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+)
+
+// defaultFailoverCheckInterval how often a reused connection checks pg_is_in_recovery() by default
+const defaultFailoverCheckInterval = 10 * time.Second
+
+// readOnlySQLTransaction error code of writes on a standby or a demoted primary
+const readOnlySQLTransaction = "25006"
+
+// isReadOnlyError whether err is a read-only transaction error
+func isReadOnlyError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.String() == readOnlySQLTransaction
+}
+
+// failoverConn a connection to the primary that evicts itself once the server is demoted
+type failoverConn struct {
+	driver.Conn
+	checkInterval time.Duration
+
+	mu        sync.Mutex
+	bad       bool
+	inTx      bool
+	checkedAt time.Time
+}
+
+func newFailoverConn(conn driver.Conn, checkInterval time.Duration) *failoverConn {
+	if checkInterval <= 0 {
+		checkInterval = defaultFailoverCheckInterval
+	}
+	return &failoverConn{Conn: conn, checkInterval: checkInterval, checkedAt: time.Now()}
+}
+
+// checkError marks the connection bad on read-only errors. Outside of transactions the statement
+// was not executed, the error becomes driver.ErrBadConn itself so that database/sql retries it on a new connection.
+func (c *failoverConn) checkError(err error) error {
+	if err == nil || !isReadOnlyError(err) {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bad = true
+	if c.inTx {
+		return err
+	}
+	return driver.ErrBadConn
+}
+
+func (c *failoverConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *failoverConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	return result, c.checkError(err)
+}
+
+func (c *failoverConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	return rows, c.checkError(err)
+}
+
+func (c *failoverConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.inTx = true
+	c.mu.Unlock()
+	return &failoverTx{Tx: tx, conn: c}, nil
+}
+
+func (c *failoverConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *failoverConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession evicts the connection before reuse when it is bad or, checked once per interval,
+// when the server is in recovery
+func (c *failoverConn) ResetSession(ctx context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		if err := resetter.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := c.Conn.(driver.QueryerContext); !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	check := time.Since(c.checkedAt) >= c.checkInterval
+	if check {
+		c.checkedAt = time.Now()
+	}
+	c.mu.Unlock()
+
+	if check {
+		var inRecovery bool
+		if err := (&SessionConn{conn: c.Conn}).QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil || inRecovery {
+			c.mu.Lock()
+			c.bad = true
+			c.mu.Unlock()
+			return driver.ErrBadConn
+		}
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator, bad connections are not returned to the pool
+func (c *failoverConn) IsValid() bool {
+	c.mu.Lock()
+	bad := c.bad
+	c.mu.Unlock()
+	if bad {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// failoverTx tracks the end of the transaction of its connection
+type failoverTx struct {
+	driver.Tx
+	conn *failoverConn
+}
+
+func (tx *failoverTx) Commit() error {
+	defer tx.end()
+	return tx.conn.checkError(tx.Tx.Commit())
+}
+
+func (tx *failoverTx) Rollback() error {
+	defer tx.end()
+	return tx.Tx.Rollback()
+}
+
+func (tx *failoverTx) end() {
+	tx.conn.mu.Lock()
+	tx.conn.inTx = false
+	tx.conn.mu.Unlock()
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// planHints the plan hints of openGauss, the ones marked true accept the no prefix
+var planHints = map[string]bool{
+	"leading":       false,
+	"nestloop":      true,
+	"hashjoin":      true,
+	"mergejoin":     true,
+	"rows":          false,
+	"tablescan":     true,
+	"indexscan":     true,
+	"indexonlyscan": true,
+	"blockname":     false,
+	"set":           false,
+	"no_expand":     false,
+}
+
+var (
+	hintMatcher     = regexp.MustCompile(`(?is)^(no\s+)?(\w+)\s*(?:\((.*)\))?$`)
+	hintNameMatcher = regexp.MustCompile(`(?is)^(no\s+)?\w+\s*`)
+	hintArgsMatcher = regexp.MustCompile(`^[\w\s."#+\-*()=]*$`)
+)
+
+// Hints the plan hints of a statement, written in a /*+ */ comment after SELECT, UPDATE or DELETE
+//
+//	db.Clauses(postgres.Hint(postgres.IndexScan("users", "idx_users_name"), postgres.Leading("users", "companies"))).Find(&users)
+type Hints struct {
+	Hints []string
+}
+
+// Hint the plan hints of a statement, invalid hints fail the statement
+func Hint(hints ...string) Hints {
+	return Hints{Hints: hints}
+}
+
+// splitHints splits hints into its top-level name(args) groups, e.g. IndexScan(users) Leading((users companies))
+// into IndexScan(users) and Leading((users companies))
+func splitHints(hints string) ([]string, error) {
+	var groups []string
+	for rest := strings.TrimSpace(hints); rest != ""; rest = strings.TrimSpace(rest) {
+		name := hintNameMatcher.FindString(rest)
+		if name == "" {
+			return nil, fmt.Errorf("invalid hint %s", rest)
+		}
+
+		end := len(name)
+		if end < len(rest) && rest[end] == '(' {
+			depth := 0
+			for end < len(rest) {
+				if rest[end] == '(' {
+					depth++
+				} else if rest[end] == ')' {
+					depth--
+				}
+				end++
+				if depth == 0 {
+					break
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in hint %s", rest)
+			}
+		}
+		groups = append(groups, strings.TrimSpace(rest[:end]))
+		rest = rest[end:]
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("empty hint")
+	}
+	return groups, nil
+}
+
+// validateHint checks the syntax of the plan hints of hint, e.g. IndexScan(users idx_users_name)
+func validateHint(hint string) error {
+	groups, err := splitHints(hint)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := validateHintGroup(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateHintGroup checks a single name(args) plan hint
+func validateHintGroup(hint string) error {
+	matches := hintMatcher.FindStringSubmatch(hint)
+	if matches == nil {
+		return fmt.Errorf("invalid hint %s", hint)
+	}
+
+	negatable, ok := planHints[strings.ToLower(matches[2])]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown hint %s", matches[2])
+	case matches[1] != "" && !negatable:
+		return fmt.Errorf("hint %s can't be negated", matches[2])
+	case strings.TrimSpace(matches[3]) == "" && !strings.EqualFold(matches[2], "no_expand"):
+		return fmt.Errorf("hint %s requires arguments", matches[2])
+	case !hintArgsMatcher.MatchString(matches[3]) || strings.Count(matches[3], "(") != strings.Count(matches[3], ")"):
+		return fmt.Errorf("invalid arguments of hint %s", hint)
+	}
+	return nil
+}
+
+func (hints Hints) String() string {
+	return "/*+ " + strings.Join(hints.Hints, " ") + " */"
+}
+
+func (hints Hints) Build(builder clause.Builder) {
+	builder.WriteString(hints.String())
+}
+
+// ModifyStatement adds the hints to the SELECT, UPDATE and DELETE clauses, the one the statement builds uses them
+func (hints Hints) ModifyStatement(stmt *gorm.Statement) {
+	for _, hint := range hints.Hints {
+		if err := validateHint(hint); err != nil {
+			stmt.AddError(err)
+			return
+		}
+	}
+
+	for _, name := range []string{"SELECT", "UPDATE"} {
+		c := stmt.Clauses[name]
+		c.AfterNameExpression = hints
+		stmt.Clauses[name] = c
+	}
+
+	// clause.Delete writes DELETE itself, the hints follow it as its modifier
+	c := stmt.Clauses["DELETE"]
+	deleteClause, _ := c.Expression.(clause.Delete)
+	deleteClause.Modifier = strings.TrimSpace(deleteClause.Modifier + " " + hints.String())
+	c.Name, c.Expression = "", deleteClause
+	stmt.Clauses["DELETE"] = c
+}
+
+// Leading joins tables in this order
+func Leading(tables ...string) string {
+	return "Leading(" + strings.Join(tables, " ") + ")"
+}
+
+// NestLoop joins tables with a nested loop
+func NestLoop(tables ...string) string {
+	return "NestLoop(" + strings.Join(tables, " ") + ")"
+}
+
+// HashJoin joins tables with a hash join
+func HashJoin(tables ...string) string {
+	return "HashJoin(" + strings.Join(tables, " ") + ")"
+}
+
+// MergeJoin joins tables with a merge join
+func MergeJoin(tables ...string) string {
+	return "MergeJoin(" + strings.Join(tables, " ") + ")"
+}
+
+// TableScan scans table sequentially
+func TableScan(table string) string {
+	return "TableScan(" + table + ")"
+}
+
+// IndexScan scans table with one of indexes, any index when none is given
+func IndexScan(table string, indexes ...string) string {
+	return "IndexScan(" + strings.Join(append([]string{table}, indexes...), " ") + ")"
+}
+
+// IndexOnlyScan scans table with one of indexes only, any index when none is given
+func IndexOnlyScan(table string, indexes ...string) string {
+	return "IndexOnlyScan(" + strings.Join(append([]string{table}, indexes...), " ") + ")"
+}
+
+// Rows corrects the row estimate of the join of tables, operator is # (set), + (add), - (subtract) or * (multiply)
+//
+//	postgres.Rows([]string{"users", "companies"}, "#", 100) // Rows(users companies #100)
+func Rows(tables []string, operator string, value float64) string {
+	return "Rows(" + strings.Join(tables, " ") + " " + operator + strconv.FormatFloat(value, 'f', -1, 64) + ")"
+}
+
+// No negates a scan or join hint, e.g. No(IndexScan("users"))
+func No(hint string) string {
+	return "no " + hint
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// IndexOptions openGauss specific index options
+//
+// They can be written into the option of the index tag, commas have to be escaped or replaced by spaces:
+//
+//	`gorm:"index:idx_user_name,option:CONCURRENTLY INCLUDE(age email) WITH(fillfactor=70) TABLESPACE fast LOCAL"`
+//
+// or returned by the model with IndexOptionsInterface.
+type IndexOptions struct {
+	Concurrently bool
+	Include      []string          // INCLUDE (columns)
+	Storage      map[string]string // WITH (storage_parameter = value)
+	Tablespace   string
+	Local        bool // LOCAL index of a partitioned table
+	Global       bool // GLOBAL index of a partitioned table
+}
+
+// IndexOptionsInterface models implementing it provide the options of their indexes by index name
+type IndexOptionsInterface interface {
+	IndexOptions() map[string]IndexOptions
+}
+
+var (
+	indexFlagMatcher       = regexp.MustCompile(`(?i)\b(CONCURRENTLY|LOCAL|GLOBAL)\b`)
+	indexIncludeMatcher    = regexp.MustCompile(`(?i)\bINCLUDE\s*\(([^)]*)\)`)
+	indexStorageMatcher    = regexp.MustCompile(`(?i)\bWITH\s*\(([^)]*)\)`)
+	indexTablespaceMatcher = regexp.MustCompile(`(?i)\bTABLESPACE\s+("[^"]+"|[\w$]+)`)
+	indexListSplitter      = regexp.MustCompile(`[\s,]+`)
+	indexAssignMatcher     = regexp.MustCompile(`\s*=\s*`)
+)
+
+// ParseIndexOption parses the option of an index tag
+func ParseIndexOption(option string) (opts IndexOptions) {
+	// flags are matched after removing the lists, a column could be named local
+	rest := option
+	if matches := indexIncludeMatcher.FindStringSubmatch(rest); len(matches) == 2 {
+		opts.Include = splitIndexList(matches[1])
+		rest = strings.Replace(rest, matches[0], " ", 1)
+	}
+	if matches := indexStorageMatcher.FindStringSubmatch(rest); len(matches) == 2 {
+		for _, param := range splitIndexList(matches[1]) {
+			if kv := strings.SplitN(param, "=", 2); len(kv) == 2 {
+				if opts.Storage == nil {
+					opts.Storage = map[string]string{}
+				}
+				opts.Storage[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+		}
+		rest = strings.Replace(rest, matches[0], " ", 1)
+	}
+	if matches := indexTablespaceMatcher.FindStringSubmatch(rest); len(matches) == 2 {
+		opts.Tablespace = strings.Trim(matches[1], `"`)
+		rest = strings.Replace(rest, matches[0], " ", 1)
+	}
+
+	for _, flag := range indexFlagMatcher.FindAllString(rest, -1) {
+		switch strings.ToUpper(flag) {
+		case "CONCURRENTLY":
+			opts.Concurrently = true
+		case "LOCAL":
+			opts.Local = true
+		case "GLOBAL":
+			opts.Global = true
+		}
+	}
+	return
+}
+
+// splitIndexList splits a list of names, `a, b` or `a b`, also `a = 1, b = 2` pairs
+func splitIndexList(list string) (results []string) {
+	list = indexAssignMatcher.ReplaceAllString(list, "=")
+	for _, item := range indexListSplitter.Split(strings.TrimSpace(list), -1) {
+		if item = strings.Trim(item, `"`); item != "" {
+			results = append(results, item)
+		}
+	}
+	return
+}
+
+// merge overrides opts with the options set in other
+func (opts IndexOptions) merge(other IndexOptions) IndexOptions {
+	opts.Concurrently = opts.Concurrently || other.Concurrently
+	opts.Local = opts.Local || other.Local
+	opts.Global = opts.Global || other.Global
+	if len(other.Include) > 0 {
+		opts.Include = other.Include
+	}
+	if other.Tablespace != "" {
+		opts.Tablespace = other.Tablespace
+	}
+	if len(other.Storage) > 0 {
+		storage := make(map[string]string, len(opts.Storage)+len(other.Storage))
+		for k, v := range opts.Storage {
+			storage[k] = v
+		}
+		for k, v := range other.Storage {
+			storage[strings.ToLower(k)] = v
+		}
+		opts.Storage = storage
+	}
+	return opts
+}
+
+// indexOptions options of idx from its tag and the model
+func indexOptions(stmt *gorm.Statement, idx *schema.Index) IndexOptions {
+	opts := ParseIndexOption(idx.Option)
+	if stmt.Schema == nil {
+		return opts
+	}
+
+	if optioner, ok := reflect.New(stmt.Schema.ModelType).Interface().(IndexOptionsInterface); ok {
+		opts = opts.merge(optioner.IndexOptions()[idx.Name])
+	}
+	return opts
+}
+
+// buildSQL the clauses following the column list of CREATE INDEX
+func (opts IndexOptions) buildSQL(stmt *gorm.Statement) string {
+	var sql strings.Builder
+	if opts.Local {
+		sql.WriteString(" LOCAL")
+	} else if opts.Global {
+		sql.WriteString(" GLOBAL")
+	}
+
+	if len(opts.Include) > 0 {
+		sql.WriteString(" INCLUDE (")
+		for idx, column := range opts.Include {
+			if idx > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(stmt.Quote(column))
+		}
+		sql.WriteString(")")
+	}
+
+	if len(opts.Storage) > 0 {
+		keys := make([]string, 0, len(opts.Storage))
+		for k := range opts.Storage {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		sql.WriteString(" WITH (")
+		for idx, k := range keys {
+			if idx > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(k + "=" + opts.Storage[k])
+		}
+		sql.WriteString(")")
+	}
+
+	if opts.Tablespace != "" {
+		sql.WriteString(" TABLESPACE " + stmt.Quote(opts.Tablespace))
+	}
+	return sql.String()
+}
+
+// IndexInfo an index as reported by the database
+type IndexInfo struct {
+	Table      string
+	Name       string
+	Columns    []string
+	Unique     bool
+	Primary    bool
+	Method     string // access method, e.g. btree
+	Where      string // predicate of a partial index
+	Definition string // pg_get_indexdef
+	IndexOptions
+}
+
+const indexInfoSql = `
+SELECT
+    t.relname,
+    i.relname,
+    ix.indisunique,
+    ix.indisprimary,
+    am.amname,
+    pg_get_indexdef(ix.indexrelid),
+    COALESCE(array_to_string(i.reloptions, ','), ''),
+    COALESCE(ts.spcname, '')
+FROM pg_index ix
+    JOIN pg_class i ON i.oid = ix.indexrelid
+    JOIN pg_class t ON t.oid = ix.indrelid
+    JOIN pg_namespace n ON n.oid = t.relnamespace
+    JOIN pg_am am ON am.oid = i.relam
+    LEFT JOIN pg_tablespace ts ON ts.oid = i.reltablespace
+WHERE t.relname = ? AND n.nspname = ?
+ORDER BY i.relname
+`
+
+var (
+	indexColumnsMatcher = regexp.MustCompile(`USING \w+ \(`)
+	indexWhereMatcher   = regexp.MustCompile(`\sWHERE\s+(.*)$`)
+	indexLocalMatcher   = regexp.MustCompile(`^\s*(LOCAL|GLOBAL)\b`)
+)
+
+// IndexInfos indexes of the table of value, with the openGauss options to detect drifts
+func (m Migrator) IndexInfos(value interface{}) ([]*IndexInfo, error) {
+	indexes := make([]*IndexInfo, 0)
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		currentSchema, curTable := m.CurrentSchema(stmt, stmt.Table)
+		rows, err := m.DB.Raw(indexInfoSql, curTable, currentSchema).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				index   = &IndexInfo{}
+				storage string
+			)
+			if err := rows.Scan(&index.Table, &index.Name, &index.Unique, &index.Primary, &index.Method,
+				&index.Definition, &storage, &index.Tablespace); err != nil {
+				return err
+			}
+			parseIndexDefinition(index)
+			for _, param := range strings.Split(storage, ",") {
+				if kv := strings.SplitN(param, "=", 2); len(kv) == 2 {
+					if index.Storage == nil {
+						index.Storage = map[string]string{}
+					}
+					index.Storage[kv[0]] = kv[1]
+				}
+			}
+			indexes = append(indexes, index)
+		}
+		return rows.Err()
+	})
+	return indexes, err
+}
+
+// parseIndexDefinition reads columns, INCLUDE, LOCAL/GLOBAL and WHERE of pg_get_indexdef
+func parseIndexDefinition(index *IndexInfo) {
+	def := index.Definition
+	if loc := indexColumnsMatcher.FindStringIndex(def); loc != nil {
+		columns, rest := splitParenthesized(def[loc[1]:])
+		for _, column := range splitTopLevel(columns) {
+			index.Columns = append(index.Columns, strings.Trim(column, `"`))
+		}
+		def = rest
+	}
+
+	if matches := indexLocalMatcher.FindStringSubmatch(def); len(matches) == 2 {
+		index.Local = matches[1] == "LOCAL"
+		index.Global = matches[1] == "GLOBAL"
+	}
+	if matches := indexIncludeMatcher.FindStringSubmatch(def); len(matches) == 2 {
+		index.Include = splitIndexList(matches[1])
+	}
+	if matches := indexWhereMatcher.FindStringSubmatch(def); len(matches) == 2 {
+		index.Where = strings.TrimSpace(matches[1])
+	}
+}
+
+// splitParenthesized splits s after the parenthesis closing an already opened one
+func splitParenthesized(s string) (inner, rest string) {
+	depth := 1
+	for idx, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return s[:idx], s[idx+1:]
+			}
+		}
+	}
+	return s, ""
+}
+
+// splitTopLevel splits s on the commas outside of parentheses, e.g. `a, lower((b)::text) DESC`
+func splitTopLevel(s string) (results []string) {
+	depth, start := 0, 0
+	for idx, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				results = append(results, strings.TrimSpace(s[start:idx]))
+				start = idx + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		results = append(results, last)
+	}
+	return
+}
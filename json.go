@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// jsonDataType is reported by the json types of this package, DataTypeOf maps it to jsonb
+const jsonDataType schema.DataType = "json"
+
+// JSON raw json value stored in a jsonb column
+type JSON json.RawMessage
+
+func (JSON) GormDataType() string {
+	return string(jsonDataType)
+}
+
+func (j *JSON) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case []byte:
+		bytes = make([]byte, len(v))
+		copy(bytes, v)
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal JSON value: %v", value)
+	}
+
+	*j = bytes
+	return nil
+}
+
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *JSON) UnmarshalJSON(b []byte) error {
+	if j == nil {
+		return errors.New("json.RawMessage: UnmarshalJSON on nil pointer")
+	}
+	*j = append((*j)[0:0], b...)
+	return nil
+}
+
+func (j JSON) String() string {
+	return string(j)
+}
+
+// JSONMap json object stored in a jsonb column
+type JSONMap map[string]interface{}
+
+func (JSONMap) GormDataType() string {
+	return string(jsonDataType)
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("failed to unmarshal JSONMap value: %v", value)
+	}
+
+	result := JSONMap{}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+	*m = result
+	return nil
+}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(map[string]interface{}(m))
+	return string(bytes), err
+}
+
+// jsonValue encodes v as a json document, JSON and json.RawMessage are used as is
+func jsonValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case JSON:
+		return string(value), nil
+	case json.RawMessage:
+		return string(value), nil
+	}
+	bytes, err := json.Marshal(v)
+	return string(bytes), err
+}
+
+// JSONQueryExpression json query expression, build conditions and extractions on a jsonb column
+//
+//	db.Where(postgres.JSONQuery("settings").HasKey("theme")).Find(&users)
+//	db.Where(postgres.JSONQuery("settings").Equals("dark", "theme", "name")).Find(&users)
+type JSONQueryExpression struct {
+	column interface{}
+	op     string
+	path   []string
+	keys   []string
+	value  interface{}
+}
+
+// JSONQuery query column as json
+func JSONQuery(column interface{}) *JSONQueryExpression {
+	return &JSONQueryExpression{column: column}
+}
+
+// Extract extract the value at path as text, column #>> '{path}'
+func (jsonQuery *JSONQueryExpression) Extract(path ...string) *JSONQueryExpression {
+	jsonQuery.op = "#>>"
+	jsonQuery.path = path
+	return jsonQuery
+}
+
+// Equals whether the value at path equals value, column #> '{path}' = value::jsonb
+func (jsonQuery *JSONQueryExpression) Equals(value interface{}, path ...string) *JSONQueryExpression {
+	jsonQuery.op = "="
+	jsonQuery.path = path
+	jsonQuery.value = value
+	return jsonQuery
+}
+
+// Contains whether the json document contains value, column @> value::jsonb
+func (jsonQuery *JSONQueryExpression) Contains(value interface{}) *JSONQueryExpression {
+	jsonQuery.op = "@>"
+	jsonQuery.value = value
+	return jsonQuery
+}
+
+// HasKey whether the key path exists, the last element is checked with the ? operator
+func (jsonQuery *JSONQueryExpression) HasKey(path ...string) *JSONQueryExpression {
+	jsonQuery.op = "?"
+	if len(path) > 0 {
+		jsonQuery.path = path[:len(path)-1]
+		jsonQuery.keys = path[len(path)-1:]
+	}
+	return jsonQuery
+}
+
+// HasAnyKey whether any of the top level keys exists, column ?| array[keys]
+func (jsonQuery *JSONQueryExpression) HasAnyKey(keys ...string) *JSONQueryExpression {
+	jsonQuery.op = "?|"
+	jsonQuery.keys = keys
+	return jsonQuery
+}
+
+// HasAllKeys whether all of the top level keys exist, column ?& array[keys]
+func (jsonQuery *JSONQueryExpression) HasAllKeys(keys ...string) *JSONQueryExpression {
+	jsonQuery.op = "?&"
+	jsonQuery.keys = keys
+	return jsonQuery
+}
+
+func (jsonQuery *JSONQueryExpression) writeColumn(builder clause.Builder) {
+	builder.WriteQuoted(jsonQuery.column)
+	if len(jsonQuery.path) > 0 {
+		builder.WriteString(" #> ")
+		builder.AddVar(builder, pq.StringArray(jsonQuery.path))
+		builder.WriteString("::text[]")
+	}
+}
+
+// Build implements clause.Expression
+func (jsonQuery *JSONQueryExpression) Build(builder clause.Builder) {
+	switch jsonQuery.op {
+	case "#>>":
+		builder.WriteQuoted(jsonQuery.column)
+		builder.WriteString(" #>> ")
+		builder.AddVar(builder, pq.StringArray(jsonQuery.path))
+		builder.WriteString("::text[]")
+	case "=", "@>":
+		value, err := jsonValue(jsonQuery.value)
+		if err != nil {
+			addBuildError(builder, err)
+			return
+		}
+		jsonQuery.writeColumn(builder)
+		builder.WriteString(" " + jsonQuery.op + " ")
+		builder.AddVar(builder, value)
+		builder.WriteString("::jsonb")
+	case "?":
+		if len(jsonQuery.keys) == 0 {
+			addBuildError(builder, errors.New("json HasKey requires a key"))
+			return
+		}
+		jsonQuery.writeColumn(builder)
+		builder.WriteString(" ? ")
+		builder.AddVar(builder, jsonQuery.keys[0])
+	case "?|", "?&":
+		builder.WriteQuoted(jsonQuery.column)
+		builder.WriteString(" " + jsonQuery.op + " ")
+		builder.AddVar(builder, pq.StringArray(jsonQuery.keys))
+		builder.WriteString("::text[]")
+	default:
+		builder.WriteQuoted(jsonQuery.column)
+	}
+}
+
+// JSONSetExpression json set expression, updates values inside a jsonb column with jsonb_set
+//
+//	db.Model(&user).Update("settings", postgres.JSONSet("settings").Set("theme", "dark").Set("limits.daily", 10))
+type JSONSetExpression struct {
+	column        interface{}
+	paths         [][]string
+	values        []interface{}
+	createMissing *bool
+}
+
+// JSONSet update fields of json column
+func JSONSet(column interface{}) *JSONSetExpression {
+	return &JSONSetExpression{column: column}
+}
+
+// Set set the value at path, nested keys are separated with '.', e.g. `limits.daily`
+func (jsonSet *JSONSetExpression) Set(path string, value interface{}) *JSONSetExpression {
+	jsonSet.paths = append(jsonSet.paths, strings.Split(path, "."))
+	jsonSet.values = append(jsonSet.values, value)
+	return jsonSet
+}
+
+// CreateMissing sets the create_missing argument of jsonb_set, defaults to the server default (true)
+func (jsonSet *JSONSetExpression) CreateMissing(createMissing bool) *JSONSetExpression {
+	jsonSet.createMissing = &createMissing
+	return jsonSet
+}
+
+// Build implements clause.Expression
+func (jsonSet *JSONSetExpression) Build(builder clause.Builder) {
+	if len(jsonSet.paths) == 0 {
+		builder.WriteQuoted(jsonSet.column)
+		return
+	}
+
+	values := make([]string, len(jsonSet.values))
+	for idx, v := range jsonSet.values {
+		value, err := jsonValue(v)
+		if err != nil {
+			addBuildError(builder, err)
+			return
+		}
+		values[idx] = value
+	}
+
+	for range jsonSet.paths {
+		builder.WriteString("jsonb_set(")
+	}
+	builder.WriteQuoted(jsonSet.column)
+	for idx, path := range jsonSet.paths {
+		builder.WriteString(", ")
+		builder.AddVar(builder, pq.StringArray(path))
+		builder.WriteString("::text[], ")
+		builder.AddVar(builder, values[idx])
+		builder.WriteString("::jsonb")
+		if jsonSet.createMissing != nil {
+			builder.WriteString(", " + strconv.FormatBool(*jsonSet.createMissing))
+		}
+		builder.WriteByte(')')
+	}
+}
+
+// addBuildError reports err on the statement being built, expressions have no error return
+func addBuildError(builder clause.Builder, err error) {
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		stmt.AddError(err)
+	}
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// reservedWords keywords of openGauss that can't be used as bare column names,
+// including the ones only allowed as function or type names
+var reservedWords = keywordSet(
+	"all", "analyse", "analyze", "and", "any", "array", "as", "asc", "asymmetric", "authid", "authorization",
+	"binary", "both", "buckets", "case", "cast", "check", "collate", "collation", "column", "concurrently",
+	"constraint", "create", "cross", "current_catalog", "current_date", "current_role", "current_schema",
+	"current_time", "current_timestamp", "current_user", "default", "deferrable", "desc", "distinct", "do",
+	"else", "end", "except", "excluded", "false", "fetch", "for", "foreign", "freeze", "from", "full", "grant",
+	"group", "groupparent", "having", "ilike", "in", "initially", "inner", "intersect", "into", "is", "isnull",
+	"join", "leading", "left", "less", "like", "limit", "localtime", "localtimestamp", "maxvalue", "minus",
+	"modify", "natural", "nlssort", "not", "notnull", "null", "offset", "on", "only", "or", "order", "outer",
+	"overlaps", "performance", "placing", "primary", "procedure", "references", "reject", "returning", "right",
+	"rownum", "select", "session_user", "shrink", "similar", "some", "symmetric", "sysdate", "table", "then",
+	"to", "trailing", "true", "union", "unique", "user", "using", "variadic", "verbose", "verify", "when",
+	"where", "window", "with",
+)
+
+// compatibilityReservedWords additional keywords of a compatibility mode
+var compatibilityReservedWords = map[string]map[string]struct{}{
+	CompatibilityA: keywordSet("connect", "level", "prior", "rowid", "start"),
+	CompatibilityB: keywordSet("div", "key", "mod", "regexp", "rlike", "xor"),
+}
+
+func keywordSet(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// IsReservedWord whether word is reserved in every compatibility mode
+func IsReservedWord(word string) bool {
+	_, ok := reservedWords[strings.ToLower(word)]
+	return ok
+}
+
+// IsReservedWord whether word is reserved in the compatibility mode of the dialector
+func (dialector Dialector) IsReservedWord(word string) bool {
+	if IsReservedWord(word) {
+		return true
+	}
+	_, ok := compatibilityReservedWords[dialector.compatibility()][strings.ToLower(word)]
+	return ok
+}
+
+// selectItemMatcher a plain column of a select list, `name`, `table.name` or `name AS alias`
+var selectItemMatcher = regexp.MustCompile(`(?i)^(?:([A-Za-z_][\w$]*)\.)?([A-Za-z_][\w$]*)(?:\s+AS\s+([A-Za-z_][\w$]*))?$`)
+
+// reservedSelectColumns splits a raw select list into columns, plain columns named with reserved
+// words are quoted, otherwise `SELECT user, level` would call current_user. ok is false when the
+// list has no such column and can be used as it is.
+func (dialector Dialector) reservedSelectColumns(selects string) (columns []clause.Column, ok bool) {
+	for _, item := range splitTopLevel(selects) {
+		matches := selectItemMatcher.FindStringSubmatch(item)
+		if matches == nil {
+			columns = append(columns, clause.Column{Name: item, Raw: true})
+			continue
+		}
+
+		// unquoted identifiers are folded to lower case by the server, keep it that way once quoted
+		table, name, alias := strings.ToLower(matches[1]), strings.ToLower(matches[2]), strings.ToLower(matches[3])
+		if dialector.IsReservedWord(name) || (table != "" && dialector.IsReservedWord(table)) {
+			ok = true
+		}
+		columns = append(columns, clause.Column{Table: table, Name: name, Alias: alias})
+	}
+	return columns, ok
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"gorm.io/gorm"
+)
+
+const (
+	listenerMinReconnectInterval = 10 * time.Second
+	listenerMaxReconnectInterval = time.Minute
+)
+
+// Notification a payload sent to a channel with NOTIFY
+type Notification struct {
+	Channel string
+	Payload string
+	PID     int // process id of the notifying backend
+	// Reconnected is set on the notification sent after the listener reconnected,
+	// notifications sent while it was disconnected are lost, e.g. caches should be flushed
+	Reconnected bool
+}
+
+func notificationOf(n *pq.Notification) Notification {
+	return Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}
+}
+
+// Listener receives the notifications of its channels on a dedicated connection, it reconnects and
+// listens to its channels again after the connection drops
+//
+//	listener, err := postgres.NewListener(db, "cache_invalidation")
+//	defer listener.Close()
+//	for notification := range listener.Notifications() {
+//		cache.Delete(notification.Payload)
+//	}
+type Listener struct {
+	listener      *pq.Listener
+	notifications chan Notification
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewListener listens to channels on a new connection to the primary of db
+//
+// The connection is opened by the connector from the DSN of db, not through the connector of the dialector:
+// the password of PasswordProvider is read once by NewListener and reused on every reconnection, so a listener
+// has to be recreated after the password is rotated, and ValidateConnect and AfterConnect are not run for it
+func NewListener(db *gorm.DB, channels ...string) (*Listener, error) {
+	dsn, err := listenerDSN(db)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Listener{
+		listener:      pq.NewListener(dsn, listenerMinReconnectInterval, listenerMaxReconnectInterval, nil),
+		notifications: make(chan Notification, 32),
+		done:          make(chan struct{}),
+	}
+	for _, channel := range channels {
+		if err := l.Listen(channel); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	go l.forward()
+	return l, nil
+}
+
+// listenerDSN the DSN of the primary of db, with the current password of PasswordProvider if any
+func listenerDSN(db *gorm.DB) (string, error) {
+	dialector := dialectorOf(db)
+	if dialector.Config == nil {
+		return "", errors.New("listener requires an openGauss dialector")
+	}
+	if dialector.PasswordProvider == nil {
+		if dialector.DSN != "" {
+			return dialector.DSN, nil
+		}
+		return configTODSN(dialector.Config), nil
+	}
+
+	config := *dialector.Config
+	if dialector.DSN != "" {
+		parsed, err := ParseDSN(dialector.DSN)
+		if err != nil {
+			return "", err
+		}
+		config = *parsed
+	}
+	password, err := dialector.PasswordProvider(context.Background())
+	if err != nil {
+		return "", err
+	}
+	config.Password = password
+	return configTODSN(&config), nil
+}
+
+// forward sends the notifications of the connector to the channel of l until it is closed, it stops on Close even
+// when nobody reads the notifications
+func (l *Listener) forward() {
+	defer close(l.notifications)
+	for {
+		select {
+		case n, ok := <-l.listener.NotificationChannel():
+			if !ok {
+				return
+			}
+			notification := Notification{Reconnected: true} // nil is sent by the connector after a reconnection
+			if n != nil {
+				notification = notificationOf(n)
+			}
+			select {
+			case l.notifications <- notification:
+			case <-l.done:
+				return
+			}
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// Notifications the notifications of the channels, closed by Close
+func (l *Listener) Notifications() <-chan Notification {
+	return l.notifications
+}
+
+// Listen starts listening to channel
+func (l *Listener) Listen(channel string) error {
+	if err := l.listener.Listen(channel); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+		return err
+	}
+	return nil
+}
+
+// Unlisten stops listening to channel
+func (l *Listener) Unlisten(channel string) error {
+	if err := l.listener.Unlisten(channel); err != nil && !errors.Is(err, pq.ErrChannelNotOpen) {
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection of the listener and its notifications channel
+func (l *Listener) Close() (err error) {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.listener.Close()
+	})
+	return
+}
+
+// Notify sends payload to the listeners of channel, within the transaction of db if any
+func Notify(db *gorm.DB, channel, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// envParams environment variables read by LoadConfig and the parameters they set,
+// each variable can also be set with the OG prefix (e.g. OGHOST), which wins over PGHOST.
+var envParams = []struct{ name, param string }{
+	{"HOST", paramHost},
+	{"PORT", paramPort},
+	{"DATABASE", "dbname"},
+	{"USER", paramUser},
+	{"PASSWORD", paramPassword},
+	{"PASSFILE", paramPassFile},
+	{"SERVICE", paramService},
+	{"SERVICEFILE", paramServiceFile},
+	{"SSLMODE", paramSSLMode},
+	{"SSLCERT", paramSSLCert},
+	{"SSLKEY", paramSSLKey},
+	{"SSLROOTCERT", paramSSLRootCert},
+	{"CONNECT_TIMEOUT", paramConnectTimeout},
+	{"APPNAME", paramApplicationName},
+	{"TARGETSESSIONATTRS", paramTargetSessionAttrs},
+	{"KRBSRVNAME", paramKrbSrvName},
+	{"CLIENTENCODING", paramClientEncoding},
+}
+
+// lookupEnv is replaced in tests
+var lookupEnv = os.LookupEnv
+
+// LoadConfig builds a Config from dsn, a service file entry, the environment and a password file,
+// with the precedence of libpq:
+//
+//  1. parameters of dsn, a keyword/value DSN or a URL, see ParseDSN
+//  2. parameters of the service named by the service parameter or PGSERVICE, read from servicefile,
+//     PGSERVICEFILE, ~/.pg_service.conf or $PGSYSCONFDIR/pg_service.conf
+//  3. PG* environment variables, OG* variables win over their PG* equivalent
+//  4. the password of the first matching line of passfile, PGPASSFILE or ~/.pgpass, only when no
+//     password is set by the above and the file is not readable by group or others
+//
+// e.g.
+//
+//	config, err := postgres.LoadConfig("dbname=mydb")
+//	db, err := gorm.Open(postgres.New(*config), &gorm.Config{})
+func LoadConfig(dsn string) (*Config, error) {
+	params := environmentParams()
+
+	dsnParams, err := dsnParams(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName, serviceFile := dsnParams[paramService], dsnParams[paramServiceFile]
+	if serviceName == "" {
+		serviceName = params[paramService]
+	}
+	if serviceFile == "" {
+		serviceFile = params[paramServiceFile]
+	}
+	if serviceName != "" {
+		serviceParams, err := readService(serviceFile, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range serviceParams {
+			params[k] = v
+		}
+	}
+
+	for k, v := range dsnParams {
+		params[k] = v
+	}
+
+	config, err := configFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Password == "" {
+		if config.Password, err = readPassFile(config.PassFile, config.Host, config.Port, config.Database, config.User); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// ConfigFromEnv builds a Config from the environment, the service and password files it refers to,
+// same as LoadConfig without a DSN
+func ConfigFromEnv() (*Config, error) {
+	return LoadConfig("")
+}
+
+func dsnParams(dsn string) (map[string]string, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return map[string]string{}, nil
+	}
+	if isURLDSN(dsn) {
+		return parseURLDSN(dsn)
+	}
+	return parseKeywordValueDSN(dsn)
+}
+
+// environmentParams parameters set by PG* and OG* environment variables
+func environmentParams() map[string]string {
+	params := map[string]string{}
+	for _, env := range envParams {
+		for _, prefix := range []string{"PG", "OG"} {
+			if v, ok := lookupEnv(prefix + env.name); ok && v != "" {
+				params[env.param] = v
+			}
+		}
+	}
+	return params
+}
+
+// serviceFiles candidate service files, file when set
+func serviceFiles(file string) []string {
+	if file != "" {
+		return []string{file}
+	}
+
+	var files []string
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".pg_service.conf"))
+	}
+	for _, prefix := range []string{"PG", "OG"} {
+		if dir, ok := lookupEnv(prefix + "SYSCONFDIR"); ok && dir != "" {
+			files = append(files, filepath.Join(dir, "pg_service.conf"))
+		}
+	}
+	return files
+}
+
+// readService reads the parameters of the service name, the first file defining it is used
+func readService(file, name string) (map[string]string, error) {
+	for _, path := range serviceFiles(file) {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) && file == "" {
+				continue
+			}
+			return nil, err
+		}
+
+		params, found, err := parseServiceFile(f, name)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if found {
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("service %q not found", name)
+}
+
+// parseServiceFile reads the `key=value` lines of the [name] section of an ini like service file
+func parseServiceFile(f *os.File, name string) (params map[string]string, found bool, err error) {
+	var (
+		scanner = bufio.NewScanner(f)
+		inside  bool
+		lineNo  int
+	)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			if inside {
+				break
+			}
+			if inside = line[1:len(line)-1] == name; inside {
+				found, params = true, map[string]string{}
+			}
+			continue
+		}
+
+		if inside {
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, false, fmt.Errorf("syntax error in line %d", lineNo)
+			}
+			params[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return params, found, scanner.Err()
+}
+
+// readPassFile the password of the first line of the password file matching the connection,
+// lines are `hostname:port:database:username:password`, `*` matches anything
+func readPassFile(file, host string, port uint16, database, user string) (string, error) {
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		file = filepath.Join(home, ".pgpass")
+	}
+
+	stat, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	// libpq ignores password files readable by others
+	if runtime.GOOS != "windows" && stat.Mode().Perm()&0077 != 0 {
+		return "", nil
+	}
+
+	if host == "" || strings.HasPrefix(host, "/") {
+		host = "localhost"
+	}
+	if port == 0 {
+		port = 5432
+	}
+	wanted := []string{host, strconv.Itoa(int(port)), database, user}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := splitPassFileLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for idx, v := range wanted {
+			if fields[idx] != "*" && fields[idx] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// splitPassFileLine splits a password file line on the first four colons, `\:` and `\\` are escapes
+func splitPassFileLine(line string) (fields []string) {
+	var field strings.Builder
+	for idx := 0; idx < len(line); idx++ {
+		switch c := line[idx]; {
+		case c == '\\' && idx+1 < len(line):
+			idx++
+			field.WriteByte(line[idx])
+		case c == ':' && len(fields) < 4:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return append(fields, field.String())
+}
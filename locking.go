@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockingStrengths the lock strengths of SELECT ... FOR
+var lockingStrengths = map[string]bool{
+	"UPDATE":        true,
+	"NO KEY UPDATE": true,
+	"SHARE":         true,
+	"KEY SHARE":     true,
+}
+
+var lockWaitMatcher = regexp.MustCompile(`^WAIT\s+(\d+)$`)
+
+// Locking the row locking clause of SELECT, clause.Locking with several OF tables and a lock wait policy
+//
+//	db.Clauses(postgres.Locking{Strength: "UPDATE", Tables: []clause.Table{{Name: "jobs"}}, SkipLocked: true}).Find(&jobs)
+type Locking struct {
+	Strength   string // UPDATE, NO KEY UPDATE, SHARE or KEY SHARE
+	Tables     []clause.Table
+	NoWait     bool
+	SkipLocked bool
+	Wait       time.Duration // how long to wait for the locks, rounded up to seconds
+}
+
+// Name implements clause.Interface, Locking replaces clause.Locking
+func (locking Locking) Name() string {
+	return "FOR"
+}
+
+func (locking Locking) Build(builder clause.Builder) {
+	builder.WriteString(strings.ToUpper(locking.Strength))
+	if len(locking.Tables) > 0 {
+		builder.WriteString(" OF ")
+		for idx, table := range locking.Tables {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(table)
+		}
+	}
+
+	switch {
+	case locking.NoWait:
+		builder.WriteString(" NOWAIT")
+	case locking.SkipLocked:
+		builder.WriteString(" SKIP LOCKED")
+	case locking.Wait > 0:
+		builder.WriteString(" WAIT ")
+		builder.WriteString(strconv.FormatInt(int64((locking.Wait+time.Second-1)/time.Second), 10))
+	}
+}
+
+func (locking Locking) MergeClause(c *clause.Clause) {
+	c.Expression = locking
+}
+
+// lockingOf converts clause.Locking, its Options are NOWAIT, SKIP LOCKED or WAIT n
+func lockingOf(c clause.Locking) (Locking, error) {
+	locking := Locking{Strength: c.Strength}
+	if c.Table.Name != "" {
+		locking.Tables = []clause.Table{c.Table}
+	}
+
+	switch options := strings.ToUpper(strings.Join(strings.Fields(c.Options), " ")); {
+	case options == "":
+	case options == "NOWAIT":
+		locking.NoWait = true
+	case options == "SKIP LOCKED":
+		locking.SkipLocked = true
+	case lockWaitMatcher.MatchString(options):
+		seconds, _ := strconv.Atoi(lockWaitMatcher.FindStringSubmatch(options)[1])
+		locking.Wait = time.Duration(seconds) * time.Second
+	default:
+		return locking, fmt.Errorf("unsupported locking option %s", c.Options)
+	}
+	return locking, nil
+}
+
+// validate checks the strength and the wait policy of locking, and the features of the server when its version is known
+func (locking Locking) validate(info *ServerInfo) error {
+	if !lockingStrengths[strings.ToUpper(locking.Strength)] {
+		return fmt.Errorf("unsupported locking strength %s", locking.Strength)
+	}
+
+	policies := 0
+	for _, set := range []bool{locking.NoWait, locking.SkipLocked, locking.Wait > 0} {
+		if set {
+			policies++
+		}
+	}
+	if policies > 1 {
+		return errors.New("NOWAIT, SKIP LOCKED and WAIT can't be combined")
+	}
+
+	if info != nil {
+		if locking.SkipLocked && info.Lacks(FeatureSkipLocked) {
+			return fmt.Errorf("SKIP LOCKED is not supported by %s %s", info.Product, info.Version)
+		}
+		if locking.Wait > 0 && info.Lacks(FeatureLockWait) {
+			return fmt.Errorf("WAIT is not supported by %s %s", info.Product, info.Version)
+		}
+	}
+	return nil
+}
+
+// buildLocking builds the FOR clause of clause.Locking and Locking, invalid clauses fail the statement
+func buildLocking(c clause.Clause, builder clause.Builder) {
+	var (
+		locking Locking
+		err     error
+	)
+	switch expr := c.Expression.(type) {
+	case Locking:
+		locking = expr
+	case clause.Locking:
+		locking, err = lockingOf(expr)
+	default:
+		c.Build(builder)
+		return
+	}
+
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		if err == nil {
+			var info *ServerInfo
+			if config := dialectorOf(stmt.DB).Config; config != nil {
+				info = config.serverInfo
+			}
+			err = locking.validate(info)
+		}
+		if err != nil {
+			stmt.AddError(err)
+			return
+		}
+	}
+
+	builder.WriteString("FOR ")
+	locking.Build(builder)
+}
+
+// ClaimRows claims up to limit rows of db into dest with SELECT ... FOR UPDATE SKIP LOCKED, rows locked by other
+// transactions are skipped, e.g. the jobs of a queue table taken by other workers. fc is called in the transaction
+// holding the locks when rows are claimed, typically to mark them as taken, which commits with it.
+//
+//	err := postgres.ClaimRows(db.Where("status = ?", "pending").Order("id"), &jobs, 10, func(tx *gorm.DB) error {
+//		return tx.Model(&jobs).Update("status", "running").Error
+//	})
+func ClaimRows(db *gorm.DB, dest interface{}, limit int, fc func(tx *gorm.DB) error) error {
+	if limit <= 0 {
+		return fmt.Errorf("invalid limit %d", limit)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(Locking{Strength: "UPDATE", SkipLocked: true}).Limit(limit).Find(dest)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return fc(tx.Session(&gorm.Session{NewDB: true}))
+	})
+}
@@ -42,8 +42,21 @@ var typeAliasMap = map[string][]string{
 	"bigint":   {"int8"},
 	"decimal":  {"numeric"},
 	"numeric":  {"decimal"},
+	// openGauss types reported by their internal names
+	"int1":    {"tinyint"},
+	"tinyint": {"int1"},
+	// array types are reported by ColumnTypes in format_type form
+	"numeric[]":                  {"decimal[]"},
+	"timestamp with time zone[]": {"timestamptz[]"},
 }
 
+// formatTypeReplacer shortens the type names of format_type to the names used by DataTypeOf
+var formatTypeReplacer = strings.NewReplacer(
+	"character varying", "varchar",
+	"bit varying", "varbit",
+	"timestamp with time zone", "timestamptz",
+)
+
 type Migrator struct {
 	migrator.Migrator
 }
@@ -72,14 +85,32 @@ func (m Migrator) BuildIndexOptions(opts []schema.IndexOption, stmt *gorm.Statem
 	return
 }
 
+// indexName resolves the database name of an index, the indexes of the model are formatted through
+// Namer.IndexName like CreateIndex does, other names are database names used as they are.
+func (m Migrator) indexName(stmt *gorm.Statement, name string) string {
+	if stmt.Schema != nil {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			_, curTable := m.CurrentSchema(stmt, stmt.Table)
+			return m.DB.NamingStrategy.IndexName(curTable.(string), idx.Name)
+		}
+	}
+	return name
+}
+
+// qualifiedIndex the index in the schema of the table
+func (m Migrator) qualifiedIndex(stmt *gorm.Statement, indexName string) clause.Table {
+	currentSchema, _ := m.CurrentSchema(stmt, stmt.Table)
+	if s, ok := currentSchema.(string); ok && s != "" {
+		return clause.Table{Name: s + "." + indexName}
+	}
+	return clause.Table{Name: indexName}
+}
+
 func (m Migrator) HasIndex(value interface{}, name string) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if idx := stmt.Schema.LookIndex(name); idx != nil {
-			name = idx.Name
-		}
 		currentSchema, curTable := m.CurrentSchema(stmt, stmt.Table)
-		indexName := m.DB.NamingStrategy.IndexName(curTable.(string), name)
+		indexName := m.dialector().catalogName(m.indexName(stmt, name))
 		return m.DB.Raw(
 			"SELECT count(*) FROM pg_indexes WHERE tablename = ? AND indexname = ? AND schemaname = ?", curTable, indexName, currentSchema,
 		).Scan(&count).Error
@@ -93,12 +124,17 @@ func (m Migrator) HasIndex(value interface{}, name string) bool {
 	return count > 0
 }
 
+// CreateIndex create index with IF NOT EXISTS when the server supports it, otherwise an existing index is left untouched
 func (m Migrator) CreateIndex(value interface{}, name string) error {
+	supportIfNotExists := m.dialector().ServerInfo().Supports(FeatureCreateIndexIfNotExists)
+	if !supportIfNotExists && m.HasIndex(value, name) {
+		return nil
+	}
+
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if idx := stmt.Schema.LookIndex(name); idx != nil {
 			opts := m.BuildIndexOptions(idx.Fields, stmt)
-			_, curTable := m.CurrentSchema(stmt, stmt.Table)
-			values := []interface{}{clause.Column{Name: m.Migrator.DB.NamingStrategy.IndexName(curTable.(string), idx.Name)}, m.CurrentTable(stmt), opts}
+			values := []interface{}{clause.Column{Name: m.indexName(stmt, idx.Name)}, m.CurrentTable(stmt), opts}
 
 			createIndexSQL := "CREATE "
 			if idx.Class != "" {
@@ -106,12 +142,16 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 			}
 			createIndexSQL += "INDEX "
 
-			if strings.TrimSpace(strings.ToUpper(idx.Option)) == "CONCURRENTLY" {
+			indexOpts := indexOptions(stmt, idx)
+			if indexOpts.Concurrently {
 				createIndexSQL += "CONCURRENTLY "
 			}
 
-			//createIndexSQL += "IF NOT EXISTS ? ON ?"
-			createIndexSQL += " ? ON ?"
+			if supportIfNotExists {
+				createIndexSQL += "IF NOT EXISTS ? ON ?"
+			} else {
+				createIndexSQL += "? ON ?"
+			}
 
 			if idx.Type != "" {
 				createIndexSQL += " USING " + idx.Type + "(?)"
@@ -119,6 +159,8 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 				createIndexSQL += " ?"
 			}
 
+			createIndexSQL += indexOpts.buildSQL(stmt)
+
 			if idx.Where != "" {
 				createIndexSQL += " WHERE " + idx.Where
 			}
@@ -133,19 +175,15 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		return m.DB.Exec(
-			"ALTER INDEX ? RENAME TO ?",
-			clause.Column{Name: oldName}, clause.Column{Name: newName},
+			"ALTER INDEX IF EXISTS ? RENAME TO ?",
+			m.qualifiedIndex(stmt, m.indexName(stmt, oldName)), clause.Column{Name: m.indexName(stmt, newName)},
 		).Error
 	})
 }
 
 func (m Migrator) DropIndex(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if idx := stmt.Schema.LookIndex(name); idx != nil {
-			name = idx.Name
-		}
-
-		return m.DB.Exec("DROP INDEX ?", clause.Column{Name: name}).Error
+		return m.DB.Exec("DROP INDEX IF EXISTS ?", m.qualifiedIndex(stmt, m.indexName(stmt, name))).Error
 	})
 }
 
@@ -234,7 +272,7 @@ func (m Migrator) HasColumn(value interface{}, field string) bool {
 		currentSchema, curTable := m.CurrentSchema(stmt, stmt.Table)
 		return m.DB.Raw(
 			"SELECT count(*) FROM INFORMATION_SCHEMA.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
-			currentSchema, curTable, name,
+			currentSchema, curTable, m.dialector().catalogName(name),
 		).Scan(&count).Error
 	})
 
@@ -300,13 +338,13 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 			isSameType := true
 			if fieldColumnType.DatabaseTypeName() != fileType.SQL {
 				isSameType = false
+				// sized types, e.g. varchar(20) or bit(8), compare with the formatted column type
+				if columnType, ok := fieldColumnType.ColumnType(); ok && formatTypeReplacer.Replace(columnType) == fileType.SQL {
+					isSameType = true
+				}
 				// if different, also check for aliases
-				aliases := m.GetTypeAliases(fieldColumnType.DatabaseTypeName())
-				for _, alias := range aliases {
-					if strings.HasPrefix(fileType.SQL, alias) {
-						isSameType = true
-						break
-					}
+				if m.isTypeAlias(fieldColumnType.DatabaseTypeName(), fileType.SQL) {
+					isSameType = true
 				}
 			}
 
@@ -361,7 +399,11 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 
 			if v, ok := fieldColumnType.DefaultValue(); (field.DefaultValueInterface == nil && ok) || v != field.DefaultValue {
 				if field.HasDefaultValue && (field.DefaultValueInterface != nil || field.DefaultValue != "") {
-					if field.DefaultValueInterface != nil {
+					if m.dialector().compatibility() == CompatibilityA && isEmptyStringDefault(field) {
+						if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? DROP DEFAULT", m.CurrentTable(stmt), clause.Column{Name: field.DBName}).Error; err != nil {
+							return err
+						}
+					} else if field.DefaultValueInterface != nil {
 						defaultStmt := &gorm.Statement{Vars: []interface{}{field.DefaultValueInterface}}
 						m.Dialector.BindVarTo(defaultStmt, defaultStmt, field.DefaultValueInterface)
 						if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? SET DEFAULT ?", m.CurrentTable(stmt), clause.Column{Name: field.DBName}, clause.Expr{SQL: m.Dialector.Explain(defaultStmt.SQL.String(), field.DefaultValueInterface)}).Error; err != nil {
@@ -413,7 +455,7 @@ func (m Migrator) HasConstraint(value interface{}, name string) bool {
 
 		return m.DB.Raw(
 			"SELECT count(*) FROM INFORMATION_SCHEMA.table_constraints WHERE table_schema = ? AND table_name = ? AND constraint_name = ?",
-			currentSchema, curTable, name,
+			currentSchema, curTable, m.dialector().catalogName(name),
 		).Scan(&count).Error
 	})
 
@@ -459,8 +501,10 @@ func (m Migrator) ColumnTypes(value interface{}) (columnTypes []gorm.ColumnType,
 				column.LengthValue = typeLenValue
 			}
 
+			// DBCOMPATIBILITY=B databases report AUTO_INCREMENT columns with an AUTO_INCREMENT default
 			if (strings.HasPrefix(column.DefaultValueValue.String, "nextval('") &&
-				strings.HasSuffix(column.DefaultValueValue.String, "seq'::regclass)")) || (identityIncrement.Valid && identityIncrement.String != "") {
+				strings.HasSuffix(column.DefaultValueValue.String, "seq'::regclass)")) || (identityIncrement.Valid && identityIncrement.String != "") ||
+				strings.EqualFold(column.DefaultValueValue.String, "AUTO_INCREMENT") {
 				column.AutoIncrementValue = sql.NullBool{Bool: true, Valid: true}
 				column.DefaultValueValue = sql.NullString{}
 			}
@@ -568,6 +612,18 @@ func (m Migrator) ColumnTypes(value interface{}) (columnTypes []gorm.ColumnType,
 			dataTypeRows.Close()
 		}
 
+		// report the names of the model for the columns folded by QuotePolicy
+		if policy := m.dialector().quotePolicy(); stmt.Schema != nil && (policy == QuoteFoldLower || policy == QuoteFoldUpper) {
+			for _, dbName := range stmt.Schema.DBNames {
+				catalogName := m.dialector().catalogName(dbName)
+				for _, c := range columnTypes {
+					if mc := c.(*migrator.ColumnType); mc.NameValue.String == catalogName {
+						mc.NameValue.String = dbName
+					}
+				}
+			}
+		}
+
 		return err
 	})
 	return
@@ -592,21 +648,22 @@ func (m Migrator) GetRows(currentSchema interface{}, table interface{}) (*sql.Ro
 }
 
 func (m Migrator) CurrentSchema(stmt *gorm.Statement, table string) (interface{}, interface{}) {
+	dialector := m.dialector()
 	if strings.Contains(table, ".") {
 		if tables := strings.Split(table, `.`); len(tables) == 2 {
-			return tables[0], tables[1]
+			return dialector.catalogName(tables[0]), dialector.catalogName(tables[1])
 		}
 	}
 
 	if stmt.TableExpr != nil {
 		if tables := strings.Split(stmt.TableExpr.SQL, `"."`); len(tables) == 2 {
-			return strings.TrimPrefix(tables[0], `"`), table
+			return strings.TrimPrefix(tables[0], `"`), dialector.catalogName(table)
 		}
 	}
 	//return clause.Expr{SQL: "CURRENT_SCHEMA()"}, table
 	var name string
 	m.DB.Raw("SELECT CURRENT_SCHEMA()").Row().Scan(&name)
-	return name, table
+	return name, dialector.catalogName(table)
 }
 
 func (m Migrator) CreateSequence(tx *gorm.DB, stmt *gorm.Statement, field *schema.Field,
@@ -616,18 +673,25 @@ func (m Migrator) CreateSequence(tx *gorm.DB, stmt *gorm.Statement, field *schem
 	tableName := table.(string)
 
 	sequenceName := strings.Join([]string{tableName, field.DBName, "seq"}, "_")
-	if err = tx.Exec(`CREATE SEQUENCE IF NOT EXISTS ? AS ?`, clause.Expr{SQL: sequenceName},
+	if namer, ok := m.DB.NamingStrategy.(interface {
+		SequenceName(table, column string) string
+	}); ok {
+		sequenceName = namer.SequenceName(tableName, field.DBName)
+	}
+	// quoted like the table, so that the names follow the QuotePolicy
+	sequence := clause.Table{Name: sequenceName}
+	if err = tx.Exec(`CREATE SEQUENCE IF NOT EXISTS ? AS ?`, sequence,
 		clause.Expr{SQL: serialDatabaseType}).Error; err != nil {
 		return err
 	}
 
 	if err := tx.Exec("ALTER TABLE ? ALTER COLUMN ? SET DEFAULT nextval('?')",
-		clause.Expr{SQL: tableName}, clause.Expr{SQL: field.DBName}, clause.Expr{SQL: sequenceName}).Error; err != nil {
+		m.CurrentTable(stmt), clause.Column{Name: field.DBName}, sequence).Error; err != nil {
 		return err
 	}
 
-	if err := tx.Exec("ALTER SEQUENCE ? OWNED BY ?.?",
-		clause.Expr{SQL: sequenceName}, clause.Expr{SQL: tableName}, clause.Expr{SQL: field.DBName}).Error; err != nil {
+	if err := tx.Exec("ALTER SEQUENCE ? OWNED BY ?",
+		sequence, clause.Column{Table: tableName, Name: field.DBName}).Error; err != nil {
 		return err
 	}
 	return
@@ -646,7 +710,7 @@ func (m Migrator) UpdateSequence(tx *gorm.DB, stmt *gorm.Statement, field *schem
 	}
 
 	if err := tx.Exec("ALTER TABLE ? ALTER COLUMN ? TYPE ?",
-		m.CurrentTable(stmt), clause.Expr{SQL: field.DBName}, clause.Expr{SQL: serialDatabaseType}).Error; err != nil {
+		m.CurrentTable(stmt), clause.Column{Name: field.DBName}, clause.Expr{SQL: serialDatabaseType}).Error; err != nil {
 		return err
 	}
 	return
@@ -665,7 +729,7 @@ func (m Migrator) DeleteSequence(tx *gorm.DB, stmt *gorm.Statement, field *schem
 	}
 
 	if err := tx.Exec("ALTER TABLE ? ALTER COLUMN ? DROP DEFAULT",
-		m.CurrentTable(stmt), clause.Expr{SQL: field.DBName}).Error; err != nil {
+		m.CurrentTable(stmt), clause.Column{Name: field.DBName}).Error; err != nil {
 		return err
 	}
 
@@ -684,7 +748,7 @@ func (m Migrator) getColumnSequenceName(tx *gorm.DB, stmt *gorm.Statement, field
 	var columnDefault string
 	err = tx.Raw(
 		`SELECT column_default FROM information_schema.columns WHERE table_name = ? AND column_name = ?`,
-		table, field.DBName).Scan(&columnDefault).Error
+		table, m.dialector().catalogName(field.DBName)).Scan(&columnDefault).Error
 
 	if err != nil {
 		return
@@ -697,39 +761,6 @@ func (m Migrator) getColumnSequenceName(tx *gorm.DB, stmt *gorm.Statement, field
 	return
 }
 
-//func (m Migrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
-//	indexes := make([]gorm.Index, 0)
-//
-//	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
-//		result := make([]*Index, 0)
-//		scanErr := m.DB.Raw(indexSql, stmt.Table).Scan(&result).Error
-//		if scanErr != nil {
-//			return scanErr
-//		}
-//		indexMap := groupByIndexName(result)
-//		for _, idx := range indexMap {
-//			tempIdx := &migrator.dm={
-//				TableName: idx[0].TableName,
-//				NameValue: idx[0].IndexName,
-//				PrimaryKeyValue: sql.NullBool{
-//					Bool:  idx[0].Primary,
-//					Valid: true,
-//				},
-//				UniqueValue: sql.NullBool{
-//					Bool:  idx[0].NonUnique,
-//					Valid: true,
-//				},
-//			}
-//			for _, x := range idx {
-//				tempIdx.ColumnList = append(tempIdx.ColumnList, x.ColumnName)
-//			}
-//			indexes = append(indexes, tempIdx)
-//		}
-//		return nil
-//	})
-//	return indexes, err
-//}
-
 // Index table index info
 type Index struct {
 	TableName  string `gorm:"column:table_name"`
@@ -748,7 +779,56 @@ func groupByIndexName(indexList []*Index) map[string][]*Index {
 }
 
 func (m Migrator) GetTypeAliases(databaseTypeName string) []string {
-	return typeAliasMap[databaseTypeName]
+	aliases := typeAliasMap[databaseTypeName]
+	if compatibilityAliases, ok := compatibilityTypeAliasMap[m.dialector().compatibility()]; ok {
+		aliases = append(aliases[:len(aliases):len(aliases)], compatibilityAliases[databaseTypeName]...)
+	}
+	return aliases
+}
+
+// typeModifierMatcher matches the size and the AUTO_INCREMENT of a data type, which its aliases don't include
+var typeModifierMatcher = regexp.MustCompile(`(?i)\([^)]*\)|\s+AUTO_INCREMENT`)
+
+// isTypeAlias whether dataType is an alias of the column type databaseTypeName. The type name of dataType without
+// its size must equal the alias, int doesn't alias int unsigned and an array type never aliases a scalar type.
+func (m Migrator) isTypeAlias(databaseTypeName, dataType string) bool {
+	typeName := strings.TrimSpace(typeModifierMatcher.ReplaceAllString(dataType, ""))
+	for _, alias := range m.GetTypeAliases(databaseTypeName) {
+		if strings.EqualFold(typeName, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Migrator) dialector() Dialector {
+	return dialectorOf(m.DB)
+}
+
+// FullDataTypeOf omits empty string defaults on DBCOMPATIBILITY=A databases, they would default to NULL,
+// and adds ON UPDATE CURRENT_TIMESTAMP to auto update time fields on DBCOMPATIBILITY=B databases.
+func (m Migrator) FullDataTypeOf(field *schema.Field) (expr clause.Expr) {
+	switch m.dialector().compatibility() {
+	case CompatibilityA:
+		if isEmptyStringDefault(field) {
+			withoutDefault := *field
+			withoutDefault.HasDefaultValue = false
+			withoutDefault.DefaultValue = ""
+			withoutDefault.DefaultValueInterface = nil
+			return m.Migrator.FullDataTypeOf(&withoutDefault)
+		}
+	case CompatibilityB:
+		expr = m.Migrator.FullDataTypeOf(field)
+		if field.AutoUpdateTime == schema.UnixTime {
+			if field.Precision > 0 {
+				expr.SQL += fmt.Sprintf(" ON UPDATE CURRENT_TIMESTAMP(%d)", field.Precision)
+			} else {
+				expr.SQL += " ON UPDATE CURRENT_TIMESTAMP"
+			}
+		}
+		return expr
+	}
+	return m.Migrator.FullDataTypeOf(field)
 }
 
 // should reset prepared stmts when table changed
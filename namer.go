@@ -1,18 +1,117 @@
 package postgres
 
 import (
-	"gorm.io/gorm/schema"
+	"crypto/sha1"
+	"encoding/hex"
 	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm/schema"
 )
 
+// MaxIdentifierLength openGauss truncates longer identifiers (NAMEDATALEN - 1 bytes)
+const MaxIdentifierLength = 63
+
+// IdentifierCase how Namer writes the generated identifiers
+type IdentifierCase int
+
+const (
+	IdentifierLowerCase    IdentifierCase = iota // snake_case in lower case, the default
+	IdentifierUpperCase                          // SNAKE_CASE in upper case, for DBCOMPATIBILITY=A schemas
+	IdentifierPreserveCase                       // names of the models are kept as they are written
+)
+
+// Namer naming strategy of openGauss, generated names never exceed MaxIdentifierLength,
+// longer ones are cut and suffixed with a hash of the full name so that they stay unique.
+//
+//	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+//		NamingStrategy: postgres.Namer{IdentifierCase: postgres.IdentifierUpperCase, ConstraintPrefix: "fk"},
+//	})
 type Namer struct {
 	schema.NamingStrategy
+	IdentifierCase   IdentifierCase
+	ConstraintPrefix string // prefix of foreign key constraint names, defaults to fk
+	CheckPrefix      string // prefix of check constraint names, defaults to chk
+	SequencePrefix   string // prepended to the table_column_seq sequence names
+}
+
+// TableName convert string to table name
+func (n Namer) TableName(str string) string {
+	return n.identifier(n.namingStrategy().TableName(str))
+}
+
+// ColumnName convert string to column name
+func (n Namer) ColumnName(table, column string) string {
+	return n.identifier(n.namingStrategy().ColumnName(table, column))
+}
+
+// JoinTableName convert string to join table name
+func (n Namer) JoinTableName(str string) string {
+	return n.identifier(n.namingStrategy().JoinTableName(str))
+}
+
+// RelationshipFKName generate fk name for relation
+func (n Namer) RelationshipFKName(rel schema.Relationship) string {
+	prefix := n.ConstraintPrefix
+	if prefix == "" {
+		prefix = "fk"
+	}
+	return n.formatName(prefix, rel.Schema.Table, n.namingStrategy().ColumnName("", rel.Name))
+}
+
+// CheckerName generate checker name
+func (n Namer) CheckerName(table, column string) string {
+	prefix := n.CheckPrefix
+	if prefix == "" {
+		prefix = "chk"
+	}
+	return n.formatName(prefix, table, column)
 }
 
+// IndexName generate index name, names starting with idx_ are used as they are
 func (n Namer) IndexName(table, column string) (name string) {
-	if !strings.HasPrefix(column, "idx_") {
-		return n.NamingStrategy.IndexName(table, column)
-	} else {
-		return column
+	if strings.HasPrefix(column, "idx_") {
+		return n.identifier(column)
+	}
+	return n.formatName("idx", table, n.namingStrategy().ColumnName("", column))
+}
+
+// SequenceName generate the name of the sequence of an auto increment column, table_column_seq like serial columns
+func (n Namer) SequenceName(table, column string) string {
+	return n.identifier(strings.Replace(n.SequencePrefix+table+"_"+column+"_seq", ".", "_", -1))
+}
+
+func (n Namer) formatName(prefix, table, name string) string {
+	return n.identifier(strings.Replace(strings.Join([]string{prefix, table, name}, "_"), ".", "_", -1))
+}
+
+// namingStrategy the embedded strategy, which must not fold the case of preserved names
+func (n Namer) namingStrategy() schema.NamingStrategy {
+	ns := n.NamingStrategy
+	if n.IdentifierCase == IdentifierPreserveCase {
+		ns.NoLowerCase = true
+	}
+	return ns
+}
+
+// identifier applies the case of n to name and shortens it to MaxIdentifierLength bytes
+func (n Namer) identifier(name string) string {
+	if n.IdentifierCase == IdentifierUpperCase {
+		name = strings.ToUpper(name)
+	}
+	if len(name) <= MaxIdentifierLength {
+		return name
+	}
+
+	sum := sha1.Sum([]byte(name))
+	suffix := "_" + hex.EncodeToString(sum[:])[:8]
+	if n.IdentifierCase == IdentifierUpperCase {
+		suffix = strings.ToUpper(suffix)
+	}
+
+	cut := MaxIdentifierLength - len(suffix)
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
 	}
+	return name[:cut] + suffix
 }
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"gorm.io/gorm/logger"
+)
+
+// NoticeHandler is called for the notices and warnings sent by the server, e.g. RAISE NOTICE in a procedure,
+// with the context of the statement that caused them
+type NoticeHandler func(ctx context.Context, notice *pq.Error)
+
+// LogNotices forwards notices to l, warnings at the Warn level, other notices at the Info level,
+// DEBUG notices are dropped. It is the default OnNotice.
+func LogNotices(l logger.Interface) NoticeHandler {
+	return func(ctx context.Context, notice *pq.Error) {
+		switch severity := strings.ToUpper(notice.Severity); {
+		case severity == "WARNING":
+			l.Warn(ctx, "%s: %s", notice.Severity, notice.Message)
+		case strings.HasPrefix(severity, "DEBUG"):
+		default:
+			l.Info(ctx, "%s: %s", notice.Severity, notice.Message)
+		}
+	}
+}
+
+type noticeCaptureKey struct{}
+
+// NoticeCapture collects the notices of the statements made with its context, see CaptureNotices
+type NoticeCapture struct {
+	mu      sync.Mutex
+	notices []*pq.Error
+}
+
+// CaptureNotices returns a context collecting the notices of the statements made with it
+//
+//	ctx, capture := postgres.CaptureNotices(ctx)
+//	db.WithContext(ctx).Exec("CALL refresh_stats()")
+//	for _, notice := range capture.Notices() {
+//		...
+//	}
+func CaptureNotices(ctx context.Context) (context.Context, *NoticeCapture) {
+	capture := &NoticeCapture{}
+	return context.WithValue(ctx, noticeCaptureKey{}, capture), capture
+}
+
+// Notices the notices collected so far
+func (capture *NoticeCapture) Notices() []*pq.Error {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	return append([]*pq.Error(nil), capture.notices...)
+}
+
+func (capture *NoticeCapture) add(notice *pq.Error) {
+	capture.mu.Lock()
+	capture.notices = append(capture.notices, notice)
+	capture.mu.Unlock()
+}
+
+// noticeConn remembers the context of the last statement of the connection, its notices are
+// sent to the handler and the NoticeCapture of that context
+type noticeConn struct {
+	driver.Conn
+	handler NoticeHandler
+
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func newNoticeConn(conn driver.Conn, handler NoticeHandler) *noticeConn {
+	c := &noticeConn{Conn: conn, handler: handler}
+	pq.SetNoticeHandler(conn, c.notice)
+	return c
+}
+
+func (c *noticeConn) use(ctx context.Context) {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+}
+
+func (c *noticeConn) notice(notice *pq.Error) {
+	c.mu.Lock()
+	ctx := c.ctx
+	c.mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if capture, ok := ctx.Value(noticeCaptureKey{}).(*NoticeCapture); ok {
+		capture.add(notice)
+	}
+	if c.handler != nil {
+		c.handler(ctx, notice)
+	}
+}
+
+func (c *noticeConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.use(ctx)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *noticeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	c.use(ctx)
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *noticeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	c.use(ctx)
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *noticeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.use(ctx)
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *noticeConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *noticeConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession forgets the context of the last statement before the connection is reused
+func (c *noticeConn) ResetSession(ctx context.Context) error {
+	c.use(nil)
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *noticeConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
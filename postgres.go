@@ -1,8 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
 	pq "gitee.com/opengauss/openGauss-connector-go-pq"
 	"gorm.io/gorm"
@@ -12,6 +14,7 @@ import (
 	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -44,7 +47,11 @@ type Config struct {
 	Database       string
 	User           string
 	Password       string
+	PassFile       string // password file used when Password is empty, see LoadConfig
+	Service        string // service of the service file providing default parameters, see LoadConfig
+	ServiceFile    string
 	TLSConfig      *tls.Config // nil disables TLS
+	SSLMode        string      // disable, allow, prefer, require, verify-ca or verify-full, derived from TLSConfig when empty
 	ConnectTimeout time.Duration
 	//DialFunc       pq.DialFunc   // e.g. net.Dialer.DialContext
 	//LookupFunc     pq.LookupFunc // e.g. net.Resolver.LookupHost
@@ -57,21 +64,27 @@ type Config struct {
 	MinReadBufferSize  int64 // The minimum size of the internal read buffer. Default 8192.
 	CpBufferSize       int64 // Defines the size of the copy buffer. Default 65535.
 
+	// PasswordProvider is called for every new physical connection and overrides Password,
+	// long-lived pools pick up rotated passwords without a restart.
+	PasswordProvider PasswordProvider
+
 	// ValidateConnect is called during a connection attempt after a successful authentication with the PostgreSQL server.
 	// It can be used to validate that the server is acceptable. If this returns an error the connection is closed and the next
 	// fallback config is tried. This allows implementing high availability behavior such as libpq does with target_session_attrs.
-
-	//ValidateConnect pq.ValidateConnectFunc
+	// See ValidatePrimary and ValidateServerVersion.
+	ValidateConnect ValidateConnectFunc
 
 	// AfterConnect is called after ValidateConnect. It can be used to set up the connection (e.g. Set session variables
 	// or prepare statements). If this returns an error the connection attempt fails.
-	// AfterConnect AfterConnectFunc
+	AfterConnect AfterConnectFunc
 
-	// OnNotice is a callback function called when a notice response is received.
-	// OnNotice NoticeHandler
+	// OnNotice is a callback function called when a notice response is received, LogNotices of the logger of
+	// the gorm.DB by default. See CaptureNotices to collect the notices of a statement.
+	OnNotice NoticeHandler
 
-	// OnNotification is a callback function called when a notification from the LISTEN/NOTIFY system is received.
-	// OnNotification NotificationHandler
+	// OnNotification is a callback function called when a notification from the LISTEN/NOTIFY system is received
+	// on a pooled connection, e.g. after a LISTEN in AfterConnect. See NewListener for a dedicated connection.
+	OnNotification func(Notification)
 
 	//CreatedByParseConfig bool // Used to enforce created by ParseConfig rule.
 
@@ -89,10 +102,44 @@ type Config struct {
 	// the encoding in the future, but for now there is this URL parameter.
 	// Enable this only if you need to override the client encoding when doing a copy.
 	AllowEncodingChanges string
+
+	// SkipInitializeWithVersion skips querying the server version, encoding and compatibility mode in Initialize,
+	// ServerInfo stays unknown and version gated features are disabled.
+	SkipInitializeWithVersion bool
+
+	serverInfo *ServerInfo
+
+	// DetectFailover evicts the connections to a primary demoted by a switchover: on read-only transaction errors
+	// (25006), and when pg_is_in_recovery() turns true, checked every FailoverCheckInterval (10s by default) when a
+	// connection is reused. Rejected statements outside of transactions are retried on a new connection, which reaches
+	// the new primary through Fallbacks with TargetSessionAttrs read-write.
+	DetectFailover        bool
+	FailoverCheckInterval time.Duration
+
+	// Standbys are the DSNs of the standby servers. When set, writes and transactions go to the primary and
+	// plain SELECT queries are balanced across the standbys, see WithPrimary, WithStandby and UsePrimary. With
+	// PrepareStmt, statements are prepared on a standby only for the reads sent there by WithStandby or UseStandby.
+	Standbys []string
+	// MaxStandbyLag excludes the standbys replaying WAL later than it from reads, checked every StandbyCheckInterval
+	// (5s by default). Standbys serve reads until their first check completes. Zero disables the check.
+	MaxStandbyLag        time.Duration
+	StandbyCheckInterval time.Duration
+
+	// Compatibility is the DBCOMPATIBILITY of the database (CompatibilityA, CompatibilityB, CompatibilityC or
+	// CompatibilityPG), it switches data types and empty string handling. Detected from pg_database when empty.
+	// With CompatibilityA, conditions comparing a column with an empty string are rewritten to IS NULL, except
+	// raw SQL conditions with more than a single comparison, e.g. Where("name = ? OR age = ?", "", 18).
+	Compatibility string
+
+	// QuotePolicy is how identifiers are quoted (QuoteAlways, QuoteWhenNeeded, QuoteFoldLower or QuoteFoldUpper),
+	// the Migrator looks up tables, columns and indexes in the system catalogs with the same case.
+	// QuoteFoldUpper requires a Namer with IdentifierUpperCase, so that the upper case result columns map to the
+	// fields, Initialize sets it on the default Namer and rejects a Namer with another case.
+	QuotePolicy QuotePolicy
 }
 
 func Open(dsn string) gorm.Dialector {
-	return &Dialector{DSN: dsn}
+	return &Dialector{DSN: dsn, Config: &Config{}}
 }
 
 func New(config Config) gorm.Dialector {
@@ -105,17 +152,20 @@ func (dialector Dialector) Name() string {
 
 //var timeZoneMatcher = regexp.MustCompile("(time_zone|TimeZone)=(.*?)($|&| )")
 
-func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
-	db.NamingStrategy = Namer{}
-	// register callbacks
-	//if !dialector.WithoutReturning {
-	callbackConfig := &callbacks.Config{
-		CreateClauses: []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"},
-		UpdateClauses: []string{"UPDATE", "SET", "WHERE", "RETURNING"},
-		DeleteClauses: []string{"DELETE", "FROM", "WHERE", "RETURNING"},
+func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
+	if dialector.Config == nil {
+		dialector.Config = &Config{}
+	}
+	switch namingStrategy := db.NamingStrategy.(type) {
+	case Namer, *Namer:
+	case schema.NamingStrategy:
+		db.NamingStrategy = Namer{NamingStrategy: namingStrategy, IdentifierCase: defaultIdentifierCase(dialector.Config.QuotePolicy)}
+	case nil:
+		db.NamingStrategy = Namer{IdentifierCase: defaultIdentifierCase(dialector.Config.QuotePolicy)}
+	}
+	if dialector.Config.QuotePolicy == QuoteFoldUpper && identifierCaseOfNamer(db.NamingStrategy) != IdentifierUpperCase {
+		return errors.New("QuoteFoldUpper requires a Namer with IdentifierUpperCase")
 	}
-	callbacks.RegisterDefaultCallbacks(db, callbackConfig)
-	//}
 
 	//if dialector.Conn != nil {
 	//	db.ConnPool = dialector.Conn
@@ -140,16 +190,49 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 	if dialector.DSN == "" {
 		dialector.DSN = configTODSN(dialector.Config)
 	}
-	var config *pq.Config
-	config, err = pq.ParseConfig(dialector.DSN)
-	if err != nil {
-		return err
+	if dialector.Config.OnNotice == nil {
+		dialector.Config.OnNotice = LogNotices(db.Logger)
 	}
-	connector, err := pq.NewConnectorConfig(config)
+	primary, err := openDB(dialector.DSN, dialector.Config)
 	if err != nil {
 		return err
 	}
-	db.ConnPool = sql.OpenDB(connector)
+	db.ConnPool = primary
+
+	if !db.DryRun && !dialector.Config.SkipInitializeWithVersion {
+		// a server that can't be detected is handled as one with an unknown version
+		if info, err := detectServerInfo(context.Background(), db.ConnPool); err != nil {
+			db.Logger.Warn(context.Background(), "failed to detect the server version, got %v", err)
+		} else {
+			dialector.Config.serverInfo = info
+			if dialector.Config.Compatibility == "" {
+				dialector.Config.Compatibility = info.Compatibility
+			}
+		}
+	}
+
+	if len(dialector.Config.Standbys) > 0 {
+		standbys, err := openStandbys(dialector.Config)
+		if err != nil {
+			return err
+		}
+		db.ConnPool = newResolverPool(primary, standbys, dialector.Config)
+	}
+
+	// register callbacks
+	//if !dialector.WithoutReturning {
+	callbackConfig := &callbacks.Config{
+		CreateClauses: []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"},
+		UpdateClauses: []string{"UPDATE", "SET", "WHERE", "RETURNING"},
+		DeleteClauses: []string{"DELETE", "FROM", "WHERE", "RETURNING"},
+	}
+	callbacks.RegisterDefaultCallbacks(db, callbackConfig)
+	//}
+
+	db.ClauseBuilders["FOR"] = buildLocking
+	if info := dialector.Config.serverInfo; info != nil && info.Lacks(FeatureOnConflict) {
+		db.ClauseBuilders["ON CONFLICT"] = onDuplicateKeyUpdate
+	}
 
 	// 替换curd 方法，对mysql的语法进行转换
 	//if err = db.Callback().Create().Replace("gorm:create", Create(callbackConfig)); err != nil {
@@ -171,6 +254,38 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 	return
 }
 
+// defaultIdentifierCase the case of the default Namer with policy
+func defaultIdentifierCase(policy QuotePolicy) IdentifierCase {
+	if policy == QuoteFoldUpper {
+		return IdentifierUpperCase
+	}
+	return IdentifierLowerCase
+}
+
+// identifierCaseOfNamer the case of the identifiers of namingStrategy, IdentifierPreserveCase when it isn't a Namer
+func identifierCaseOfNamer(namingStrategy schema.Namer) IdentifierCase {
+	switch namer := namingStrategy.(type) {
+	case Namer:
+		return namer.IdentifierCase
+	case *Namer:
+		return namer.IdentifierCase
+	}
+	return IdentifierPreserveCase
+}
+
+// openDB opens the pool of dsn, with the connection hooks of config
+func openDB(dsn string, config *Config) (*sql.DB, error) {
+	pqConfig, err := pq.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := newConnector(pqConfig, config)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
 // # Example DSN
 // user=jack password=secret host=pg.example.com port=5432 dbname=mydb sslmode=verify-ca
 func configTODSN(config *Config) string {
@@ -181,21 +296,32 @@ func configTODSN(config *Config) string {
 		}
 		s.WriteString(k)
 		s.WriteString("=")
-		s.WriteString(v)
+		s.WriteString(quoteDSNValue(v))
 		s.WriteString(" ")
 	}
+	portOf := func(port uint16) string {
+		if port == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(port))
+	}
 
 	// Fallbacks     []*pq.FallbackConfig
-	host, port := config.Host, strconv.Itoa(int(config.Port))
+	host, port := config.Host, portOf(config.Port)
 	for _, fallback := range config.Fallbacks {
 		host += "," + fallback.Host
-		port += "," + strconv.Itoa(int(fallback.Port))
+		port += "," + portOf(fallback.Port)
 	}
 	builder(paramHost, host)
-	builder(paramPort, port)
+	if strings.Trim(port, ",") != "" {
+		builder(paramPort, port)
+	}
 	builder("dbname", config.Database)
 	builder(paramUser, config.User)
 	builder(paramPassword, config.Password)
+	builder(paramPassFile, config.PassFile)
+	builder(paramService, config.Service)
+	builder(paramServiceFile, config.ServiceFile)
 	if config.ConnectTimeout != 0 {
 		builder(paramConnectTimeout, fmt.Sprintf("%d", int(config.ConnectTimeout.Seconds()))) // time.Duration(timeout) * time.Second
 	}
@@ -205,11 +331,15 @@ func configTODSN(config *Config) string {
 	builder(paramMinReadBufferSize, strconv.FormatInt(config.MinReadBufferSize, 10))
 	builder(paramTargetSessionAttrs, config.TargetSessionAttrs)
 
-	for k, v := range config.RuntimeParams {
-		builder(k, v)
-	}
-	for k, v := range config.GssAPIParams {
-		builder(k, v)
+	for _, params := range []map[string]string{config.RuntimeParams, config.GssAPIParams} {
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			builder(k, params[k])
+		}
 	}
 
 	switch config.LogLevel {
@@ -228,7 +358,9 @@ func configTODSN(config *Config) string {
 	}
 
 	// TODO: tls undone.
-	if config.TLSConfig == nil {
+	if config.SSLMode != "" {
+		builder(paramSSLMode, config.SSLMode)
+	} else if config.TLSConfig == nil {
 		builder(paramSSLMode, "disable")
 	} else if config.TLSConfig.InsecureSkipVerify {
 		builder(paramSSLMode, "allow")
@@ -270,9 +402,13 @@ const (
 )
 
 func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	if dialector.Config != nil && len(dialector.Config.Standbys) > 0 {
+		// the catalogs of the standbys may lag behind the DDL
+		db = db.WithContext(WithPrimary(db.Statement.Context))
+	}
 	return Migrator{migrator.Migrator{Config: migrator.Config{
 		DB:                          db,
-		Dialector:                   dialector,
+		Dialector:                   &dialector,
 		CreateIndexAfterCreateTable: true,
 	}}}
 }
@@ -287,6 +423,11 @@ func (dialector Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement,
 }
 
 func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
+	if policy := dialector.quotePolicy(); policy != QuoteAlways {
+		dialector.quoteWithPolicy(writer, str, policy)
+		return
+	}
+
 	var (
 		underQuoted, selfQuoted bool
 		continuousBacktick      int8
@@ -336,12 +477,30 @@ func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
 
 var numericPlaceholder = regexp.MustCompile(`\$(\d+)`)
 
+// Explain interpolates vars into sql for logs and the Migrator, see ExplainQuery for query plans
 func (dialector Dialector) Explain(sql string, vars ...interface{}) string {
-	fmt.Println(logger.ExplainSQL(sql, numericPlaceholder, `'`, vars...))
 	return logger.ExplainSQL(sql, numericPlaceholder, `'`, vars...)
 }
 
 func (dialector Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.Serializer.(type) {
+	case IntervalSerializer:
+		return string(intervalDataType)
+	case ArraySerializer:
+		return dialector.arrayDataTypeOf(field)
+	}
+
+	switch dialector.compatibility() {
+	case CompatibilityA:
+		if sqlType, ok := dialector.oracleDataTypeOf(field); ok {
+			return sqlType
+		}
+	case CompatibilityB:
+		if sqlType, ok := dialector.mysqlDataTypeOf(field); ok {
+			return sqlType
+		}
+	}
+
 	switch field.DataType {
 	case schema.Bool:
 		return "boolean"
@@ -361,6 +520,8 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 			}
 		} else {
 			switch {
+			case field.DataType == schema.Uint && field.Size <= 8:
+				return "tinyint"
 			case size <= 16:
 				return "smallint"
 			case size <= 32:
@@ -389,6 +550,20 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 		return "timestamptz"
 	case schema.Bytes:
 		return "bytea"
+	case arrayDataType:
+		return dialector.arrayDataTypeOf(field)
+	case jsonDataType:
+		return "jsonb"
+	case bitDataType:
+		if field.Size > 0 {
+			return fmt.Sprintf("bit(%d)", field.Size)
+		}
+		return "bit(1)"
+	case varBitDataType:
+		if field.Size > 0 {
+			return fmt.Sprintf("varbit(%d)", field.Size)
+		}
+		return "varbit"
 	default:
 		return dialector.getSchemaCustomType(field)
 	}
@@ -397,6 +572,13 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 func (dialector Dialector) getSchemaCustomType(field *schema.Field) string {
 	sqlType := string(field.DataType)
 
+	if field.AutoIncrement && dialector.compatibility() == CompatibilityB {
+		if !strings.Contains(strings.ToUpper(sqlType), "AUTO_INCREMENT") {
+			sqlType += " AUTO_INCREMENT"
+		}
+		return sqlType
+	}
+
 	if field.AutoIncrement && !strings.Contains(strings.ToLower(sqlType), "serial") {
 		size := field.Size
 		if field.GORMDataType == schema.Uint {
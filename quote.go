@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// QuotePolicy how QuoteTo writes identifiers
+type QuotePolicy int
+
+const (
+	QuoteAlways     QuotePolicy = iota // quote identifiers as they are, the default
+	QuoteWhenNeeded                    // only quote identifiers with upper case or special characters and reserved words
+	QuoteFoldLower                     // fold identifiers to lower case and quote them, reaches tables created unquoted
+	QuoteFoldUpper                     // fold identifiers to upper case and quote them, reaches tables created in upper case
+)
+
+// bareIdentifierMatcher identifiers the server keeps as they are when unquoted
+var bareIdentifierMatcher = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+func (dialector Dialector) quotePolicy() QuotePolicy {
+	if dialector.Config == nil {
+		return QuoteAlways
+	}
+	return dialector.Config.QuotePolicy
+}
+
+// quoteWithPolicy writes the dot separated identifiers of str with policy,
+// parts already quoted are written as they are
+func (dialector Dialector) quoteWithPolicy(writer clause.Writer, str string, policy QuotePolicy) {
+	for idx, part := range splitIdentifier(str) {
+		if idx > 0 {
+			writer.WriteByte('.')
+		}
+
+		switch {
+		case len(part) >= 2 && part[0] == '"' && part[len(part)-1] == '"':
+			writer.WriteString(part)
+		case policy == QuoteWhenNeeded && !dialector.needsQuote(part):
+			writer.WriteString(part)
+		default:
+			writer.WriteByte('"')
+			writer.WriteString(strings.ReplaceAll(dialector.foldIdentifier(part), `"`, `""`))
+			writer.WriteByte('"')
+		}
+	}
+}
+
+// needsQuote whether the server would change or reject the unquoted identifier
+func (dialector Dialector) needsQuote(identifier string) bool {
+	return !bareIdentifierMatcher.MatchString(identifier) || dialector.IsReservedWord(identifier)
+}
+
+// foldIdentifier the case of identifier once written by QuoteTo
+func (dialector Dialector) foldIdentifier(identifier string) string {
+	switch dialector.quotePolicy() {
+	case QuoteFoldLower:
+		return strings.ToLower(identifier)
+	case QuoteFoldUpper:
+		return strings.ToUpper(identifier)
+	}
+	return identifier
+}
+
+// catalogName the name of identifier in the system catalogs, as created by QuoteTo
+func (dialector Dialector) catalogName(identifier string) string {
+	if len(identifier) >= 2 && identifier[0] == '"' && identifier[len(identifier)-1] == '"' {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], `""`, `"`)
+	}
+	return dialector.foldIdentifier(identifier)
+}
+
+// splitIdentifier splits str on the dots outside of double quotes
+func splitIdentifier(str string) (parts []string) {
+	var quoted bool
+	start := 0
+	for idx := 0; idx < len(str); idx++ {
+		switch str[idx] {
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				parts = append(parts, str[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(parts, str[start:])
+}
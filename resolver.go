@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultStandbyCheckInterval how often the replication lag of a standby is checked by default
+const defaultStandbyCheckInterval = 5 * time.Second
+
+// standbyLagSQL replication lag of a standby in seconds, 0 when it replayed everything it received
+const standbyLagSQL = `SELECT CASE WHEN NOT pg_is_in_recovery() OR pg_last_xlog_receive_location() = pg_last_xlog_replay_location() THEN 0
+ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+
+type route int
+
+const (
+	routeAuto route = iota
+	routePrimary
+	routeStandby
+)
+
+type routeKey struct{}
+
+// WithPrimary sends the queries made with ctx to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routePrimary)
+}
+
+// WithStandby starts the read only transactions made with ctx on a standby. Its queries go to a standby when they
+// are reads only, writes such as INSERT ... RETURNING always go to the primary.
+func WithStandby(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routeStandby)
+}
+
+func routeOf(ctx context.Context) route {
+	if ctx == nil {
+		return routeAuto
+	}
+	r, _ := ctx.Value(routeKey{}).(route)
+	return r
+}
+
+// routeClause sets the route of a statement, see UsePrimary and UseStandby
+type routeClause route
+
+// UsePrimary and UseStandby set the route of a statement like WithPrimary and WithStandby, writes always go to the
+// primary
+//
+//	db.Clauses(postgres.UsePrimary).First(&user)
+var (
+	UsePrimary clause.Expression = routeClause(routePrimary)
+	UseStandby clause.Expression = routeClause(routeStandby)
+)
+
+func (r routeClause) ModifyStatement(stmt *gorm.Statement) {
+	stmt.Context = context.WithValue(stmt.Context, routeKey{}, route(r))
+}
+
+// Build implements clause.Expression, the route is not part of the SQL
+func (r routeClause) Build(clause.Builder) {}
+
+var (
+	selectMatcher  = regexp.MustCompile(`(?is)^\s*SELECT\b`)
+	withMatcher    = regexp.MustCompile(`(?is)^\s*WITH\b`)
+	writeMatcher   = regexp.MustCompile(`(?is)\b(INSERT|UPDATE|DELETE|MERGE)\b`)
+	lockingMatcher = regexp.MustCompile(`(?is)\bFOR\s+(UPDATE|SHARE|NO\s+KEY\s+UPDATE|KEY\s+SHARE)\b|\b(nextval|setval)\s*\(`)
+)
+
+// isReadQuery whether query can run on a standby, plain SELECT, or WITH queries without data-modifying statements,
+// without locking or sequence changes
+func isReadQuery(query string) bool {
+	if !selectMatcher.MatchString(query) && !(withMatcher.MatchString(query) && !writeMatcher.MatchString(query)) {
+		return false
+	}
+	return !lockingMatcher.MatchString(query)
+}
+
+// standby a standby pool and its replication lag state
+type standby struct {
+	db        *sql.DB
+	mu        sync.Mutex
+	excluded  bool
+	checking  bool
+	checkedAt time.Time
+}
+
+// resolverPool sends writes and transactions to the primary and reads to the standbys
+type resolverPool struct {
+	primary       *sql.DB
+	standbys      []*standby
+	maxLag        time.Duration
+	checkInterval time.Duration
+	next          uint32
+}
+
+func newResolverPool(primary *sql.DB, standbys []*sql.DB, config *Config) *resolverPool {
+	pool := &resolverPool{primary: primary, maxLag: config.MaxStandbyLag, checkInterval: config.StandbyCheckInterval}
+	if pool.checkInterval <= 0 {
+		pool.checkInterval = defaultStandbyCheckInterval
+	}
+	for _, db := range standbys {
+		pool.standbys = append(pool.standbys, &standby{db: db})
+	}
+	return pool
+}
+
+// reader the pool of a query, a standby for reads unless the primary is required by ctx
+func (pool *resolverPool) reader(ctx context.Context, query string) *sql.DB {
+	if routeOf(ctx) == routePrimary || !isReadQuery(query) {
+		return pool.primary
+	}
+	return pool.standby()
+}
+
+// standby a standby that isn't lagging, round robin, the primary when no standby is available
+func (pool *resolverPool) standby() *sql.DB {
+	start := atomic.AddUint32(&pool.next, 1)
+	for idx := range pool.standbys {
+		s := pool.standbys[(int(start)+idx)%len(pool.standbys)]
+		if pool.available(s) {
+			return s.db
+		}
+	}
+	return pool.primary
+}
+
+// preparer the pool of a prepared statement, a standby for reads sent there by ctx only: gorm caches prepared
+// statements by their SQL and reuses them within transactions, which database/sql only allows for the statements
+// of the primary
+func (pool *resolverPool) preparer(ctx context.Context, query string) *sql.DB {
+	if routeOf(ctx) != routeStandby || !isReadQuery(query) {
+		return pool.primary
+	}
+	return pool.standby()
+}
+
+// available whether s can serve reads, its lag is checked in the background once per check interval, standbys
+// serve reads until their first check excludes them
+func (pool *resolverPool) available(s *standby) bool {
+	if pool.maxLag <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.checking && time.Since(s.checkedAt) >= pool.checkInterval {
+		s.checking = true
+		go pool.checkLag(s)
+	}
+	return !s.excluded
+}
+
+func (pool *resolverPool) checkLag(s *standby) {
+	ctx, cancel := context.WithTimeout(context.Background(), pool.checkInterval)
+	defer cancel()
+
+	var lag float64
+	err := s.db.QueryRowContext(ctx, standbyLagSQL).Scan(&lag)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checking = false
+	s.checkedAt = time.Now()
+	s.excluded = err != nil || time.Duration(lag*float64(time.Second)) > pool.maxLag
+}
+
+func (pool *resolverPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return pool.preparer(ctx, query).PrepareContext(ctx, query)
+}
+
+func (pool *resolverPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return pool.primary.ExecContext(ctx, query, args...)
+}
+
+func (pool *resolverPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return pool.reader(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (pool *resolverPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return pool.reader(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts transactions on the primary, read only ones sent to a standby by the context excepted
+func (pool *resolverPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts != nil && opts.ReadOnly && routeOf(ctx) == routeStandby {
+		return pool.standby().BeginTx(ctx, opts)
+	}
+	return pool.primary.BeginTx(ctx, opts)
+}
+
+// GetDBConn the primary, db.DB() configures and closes the primary pool only, see StandbyDBs
+func (pool *resolverPool) GetDBConn() (*sql.DB, error) {
+	return pool.primary, nil
+}
+
+// StandbyDBs the pools of the standbys of db, to configure or close them
+func StandbyDBs(db *gorm.DB) []*sql.DB {
+	pool, ok := db.ConnPool.(*resolverPool)
+	if !ok {
+		return nil
+	}
+	dbs := make([]*sql.DB, len(pool.standbys))
+	for idx, s := range pool.standbys {
+		dbs[idx] = s.db
+	}
+	return dbs
+}
+
+// openStandbys opens the pools of the standby DSNs, with the hooks of config except ValidateConnect
+// and DetectFailover, which reject standbys
+func openStandbys(config *Config) ([]*sql.DB, error) {
+	hooks := *config
+	hooks.ValidateConnect, hooks.DetectFailover = nil, false
+
+	dbs := make([]*sql.DB, 0, len(config.Standbys))
+	for _, dsn := range config.Standbys {
+		db, err := openDB(strings.TrimSpace(dsn), &hooks)
+		if err != nil {
+			for _, opened := range dbs {
+				opened.Close()
+			}
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Feature a server capability that callbacks and the Migrator gate on
+type Feature string
+
+const (
+	FeatureReturning              Feature = "RETURNING"
+	FeatureOnConflict             Feature = "ON CONFLICT"
+	FeatureIdentityColumns        Feature = "IDENTITY COLUMNS"
+	FeatureCreateIndexIfNotExists Feature = "CREATE INDEX IF NOT EXISTS"
+	FeatureSkipLocked             Feature = "SKIP LOCKED"
+	FeatureLockWait               Feature = "FOR UPDATE WAIT"
+)
+
+// featureVersions minimum openGauss kernel version of the features,
+// MogDB follows the openGauss version numbers.
+var featureVersions = map[Feature][3]int{
+	FeatureReturning:              {1, 0, 0},
+	FeatureSkipLocked:             {3, 0, 0},
+	FeatureLockWait:               {3, 0, 0},
+	FeatureOnConflict:             {5, 0, 0},
+	FeatureCreateIndexIfNotExists: {5, 0, 0},
+	FeatureIdentityColumns:        {6, 0, 0},
+}
+
+const (
+	serverInfoSQL = "SELECT version(), current_setting('server_encoding'), datcompatibility FROM pg_database WHERE datname = current_database()"
+	// serverVersionSQL is used when pg_database has no datcompatibility, e.g. on PostgreSQL
+	serverVersionSQL = "SELECT version(), current_setting('server_encoding')"
+)
+
+// version() output, e.g. `PostgreSQL 9.2.4 (openGauss 3.0.0 build 02c14696) compiled at ...`
+var serverVersionMatcher = regexp.MustCompile(`\(([A-Za-z][\w ]*?)\s+(V?[\w.]+)\s+build`)
+
+// gaussDBKernelVersions the openGauss kernel versions of the GaussDB Kernel releases, by the prefix of their version
+var gaussDBKernelVersions = []struct {
+	release string
+	version [3]int
+}{
+	{"V500R001C10", [3]int{1, 1, 0}},
+	{"V500R001C20", [3]int{2, 0, 0}},
+	{"V500R002C00", [3]int{3, 0, 0}},
+	{"V500R002C10", [3]int{3, 0, 0}},
+}
+
+// ServerInfo the server Initialize connected to
+type ServerInfo struct {
+	Product       string // openGauss, MogDB, GaussDB Kernel...
+	Version       string // e.g. 3.0.0, or V500R002C10 for GaussDB
+	Major         int    // Major, Minor and Patch are the openGauss kernel version, 0 when it is unknown
+	Minor         int
+	Patch         int
+	Compatibility string // datcompatibility of the current database
+	Encoding      string // server_encoding
+	VersionString string // output of version()
+}
+
+// parseServerVersion parses the output of version()
+func parseServerVersion(version string) ServerInfo {
+	info := ServerInfo{VersionString: version}
+	if matches := serverVersionMatcher.FindStringSubmatch(version); len(matches) == 3 {
+		info.Product, info.Version = matches[1], matches[2]
+
+		var numbers [3]int
+		for idx, number := range strings.SplitN(info.Version, ".", 3) {
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				numbers = kernelVersionOf(info.Version)
+				break
+			}
+			numbers[idx] = n
+		}
+		info.Major, info.Minor, info.Patch = numbers[0], numbers[1], numbers[2]
+	}
+	return info
+}
+
+// kernelVersionOf the openGauss kernel version of a GaussDB Kernel release, zero when it is unknown
+func kernelVersionOf(release string) [3]int {
+	for _, kernel := range gaussDBKernelVersions {
+		if strings.HasPrefix(strings.ToUpper(release), kernel.release) {
+			return kernel.version
+		}
+	}
+	return [3]int{}
+}
+
+// Known whether the version of the server is known
+func (info ServerInfo) Known() bool {
+	return info.Major > 0
+}
+
+// AtLeast whether the server version is at least major.minor.patch, an unknown version never is
+func (info ServerInfo) AtLeast(major, minor, patch int) bool {
+	if !info.Known() {
+		return false
+	}
+	if info.Major != major {
+		return info.Major > major
+	}
+	if info.Minor != minor {
+		return info.Minor > minor
+	}
+	return info.Patch >= patch
+}
+
+// Supports whether the server supports feature, servers with an unknown version only support RETURNING
+func (info ServerInfo) Supports(feature Feature) bool {
+	if feature == FeatureReturning {
+		return true
+	}
+	if version, ok := featureVersions[feature]; ok {
+		return info.AtLeast(version[0], version[1], version[2])
+	}
+	return false
+}
+
+// Lacks whether the server is known to lack feature, the version is known and older than the feature. Unlike
+// Supports, servers with an unknown version lack nothing, the statements using the feature are sent as they are.
+func (info ServerInfo) Lacks(feature Feature) bool {
+	return info.Known() && !info.Supports(feature)
+}
+
+// ServerInfo returns the server detected by Initialize
+func (dialector Dialector) ServerInfo() ServerInfo {
+	if dialector.Config == nil || dialector.Config.serverInfo == nil {
+		return ServerInfo{Compatibility: dialector.compatibility()}
+	}
+	return *dialector.Config.serverInfo
+}
+
+// ServerInfoOf returns the server db is connected to
+func ServerInfoOf(db *gorm.DB) ServerInfo {
+	return dialectorOf(db).ServerInfo()
+}
+
+// detectServerInfo queries version(), server_encoding and datcompatibility once, the compatibility is empty
+// when the server has no datcompatibility
+func detectServerInfo(ctx context.Context, connPool gorm.ConnPool) (*ServerInfo, error) {
+	var version, encoding, compatibility string
+	if err := connPool.QueryRowContext(ctx, serverInfoSQL).Scan(&version, &encoding, &compatibility); err != nil {
+		if err := connPool.QueryRowContext(ctx, serverVersionSQL).Scan(&version, &encoding); err != nil {
+			return nil, err
+		}
+	}
+
+	info := parseServerVersion(version)
+	info.Encoding = encoding
+	info.Compatibility = compatibility
+	return &info, nil
+}
+
+// onDuplicateKeyUpdate renders clause.OnConflict as ON DUPLICATE KEY UPDATE for servers without ON CONFLICT,
+// the conflict target is not supported there, any unique violation triggers the update. The excluded row doesn't
+// exist either, its columns are written as VALUES(column).
+func onDuplicateKeyUpdate(c clause.Clause, builder clause.Builder) {
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+
+	builder.WriteString("ON DUPLICATE KEY UPDATE ")
+	if onConflict.DoNothing || len(onConflict.DoUpdates) == 0 {
+		builder.WriteString("NOTHING")
+		return
+	}
+	for idx, assignment := range onConflict.DoUpdates {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(assignment.Column)
+		builder.WriteByte('=')
+		if column, ok := assignment.Value.(clause.Column); ok && column.Table == "excluded" {
+			builder.WriteString("VALUES(")
+			builder.WriteQuoted(column.Name)
+			builder.WriteByte(')')
+		} else {
+			builder.AddVar(builder, assignment.Value)
+		}
+	}
+}
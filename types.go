@@ -0,0 +1,576 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// openGauss specific data types, DataTypeOf adds the size of bit and varbit columns
+const (
+	int16DataType         schema.DataType = "int16"
+	smallDateTimeDataType schema.DataType = "smalldatetime"
+	moneyDataType         schema.DataType = "money"
+	intervalDataType      schema.DataType = "interval"
+	inetDataType          schema.DataType = "inet"
+	cidrDataType          schema.DataType = "cidr"
+	macAddrDataType       schema.DataType = "macaddr"
+	uuidDataType          schema.DataType = "uuid"
+	bitDataType           schema.DataType = "bit"
+	varBitDataType        schema.DataType = "varbit"
+	xmlDataType           schema.DataType = "xml"
+)
+
+// asString returns the textual form of a value received from the connector
+func asString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+// Int128 maps to int16, the 16 bytes integer of openGauss
+type Int128 struct {
+	big.Int
+}
+
+func (Int128) GormDataType() string {
+	return string(int16DataType)
+}
+
+func (i *Int128) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		i.SetInt64(0)
+		return nil
+	case int64:
+		i.SetInt64(v)
+		return nil
+	}
+
+	if s, ok := asString(value); ok {
+		if _, ok := i.SetString(strings.TrimSpace(s), 10); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to scan Int128 value: %v", value)
+}
+
+func (i Int128) Value() (driver.Value, error) {
+	return i.String(), nil
+}
+
+// SmallDateTime maps to smalldatetime, a timestamp rounded to the minute
+type SmallDateTime time.Time
+
+const smallDateTimeLayout = "2006-01-02 15:04:05"
+
+func (SmallDateTime) GormDataType() string {
+	return string(smallDateTimeDataType)
+}
+
+func (t *SmallDateTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = SmallDateTime{}
+		return nil
+	case time.Time:
+		*t = SmallDateTime(v)
+		return nil
+	}
+
+	if s, ok := asString(value); ok {
+		parsed, err := time.Parse(smallDateTimeLayout, s)
+		if err != nil {
+			return err
+		}
+		*t = SmallDateTime(parsed)
+		return nil
+	}
+	return fmt.Errorf("failed to scan SmallDateTime value: %v", value)
+}
+
+func (t SmallDateTime) Value() (driver.Value, error) {
+	return time.Time(t).Format(smallDateTimeLayout), nil
+}
+
+func (t SmallDateTime) String() string {
+	return time.Time(t).Format(smallDateTimeLayout)
+}
+
+// Money maps to money, the value is kept as a plain decimal string, e.g. `-1234.50`
+type Money string
+
+// currency symbols and group separators in the lc_monetary dependent output of money
+var moneyNoise = regexp.MustCompile(`[^0-9.\-]`)
+
+func (Money) GormDataType() string {
+	return string(moneyDataType)
+}
+
+func (m *Money) Scan(value interface{}) error {
+	if value == nil {
+		*m = ""
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan Money value: %v", value)
+	}
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	s = moneyNoise.ReplaceAllString(s, "")
+	if negative && !strings.HasPrefix(s, "-") {
+		s = "-" + s
+	}
+	*m = Money(s)
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	if m == "" {
+		return nil, nil
+	}
+	return string(m), nil
+}
+
+// Interval maps to interval, months and years are counted as 30 and 365 days
+type Interval time.Duration
+
+var intervalUnits = map[string]time.Duration{
+	"year":  365 * 24 * time.Hour,
+	"years": 365 * 24 * time.Hour,
+	"mon":   30 * 24 * time.Hour,
+	"mons":  30 * 24 * time.Hour,
+	"day":   24 * time.Hour,
+	"days":  24 * time.Hour,
+}
+
+func (Interval) GormDataType() string {
+	return string(intervalDataType)
+}
+
+func (i *Interval) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*i = 0
+		return nil
+	case int64:
+		*i = Interval(time.Duration(v) * time.Microsecond)
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan Interval value: %v", value)
+	}
+	d, err := parseInterval(s)
+	if err != nil {
+		return err
+	}
+	*i = Interval(d)
+	return nil
+}
+
+func (i Interval) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d microseconds", time.Duration(i).Microseconds()), nil
+}
+
+func (i Interval) Duration() time.Duration {
+	return time.Duration(i)
+}
+
+func init() {
+	schema.RegisterSerializer("interval", IntervalSerializer{})
+}
+
+// IntervalSerializer stores time.Duration fields, or other int64 based durations, in interval columns
+//
+//	type Job struct {
+//		Timeout time.Duration `gorm:"serializer:interval"`
+//	}
+type IntervalSerializer struct{}
+
+// Scan implements schema.SerializerInterface, NULL sets the zero value or a nil pointer
+func (IntervalSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.Zero(field.FieldType)
+	if dbValue != nil {
+		var interval Interval
+		if err := interval.Scan(dbValue); err != nil {
+			return err
+		}
+
+		if field.FieldType.Kind() == reflect.Ptr {
+			fieldValue = reflect.New(field.FieldType.Elem())
+			fieldValue.Elem().SetInt(int64(interval))
+		} else {
+			fieldValue = reflect.New(field.FieldType).Elem()
+			fieldValue.SetInt(int64(interval))
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (IntervalSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value := reflect.ValueOf(fieldValue)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Int64 {
+		return nil, fmt.Errorf("failed to serialize %T to interval", fieldValue)
+	}
+	return Interval(value.Int()).Value()
+}
+
+// parseInterval parses the postgres IntervalStyle output, e.g. `1 year 2 mons 3 days -04:05:06.5`
+func parseInterval(s string) (d time.Duration, err error) {
+	fields := strings.Fields(s)
+	for idx := 0; idx < len(fields); idx++ {
+		field := fields[idx]
+		if strings.Contains(field, ":") {
+			clock, err := parseIntervalClock(field)
+			if err != nil {
+				return 0, err
+			}
+			d += clock
+			continue
+		}
+
+		if idx+1 >= len(fields) {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		unit, ok := intervalUnits[fields[idx+1]]
+		if !ok {
+			return 0, fmt.Errorf("invalid interval unit %q in %q", fields[idx+1], s)
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %q: %w", s, err)
+		}
+		d += time.Duration(n) * unit
+		idx++
+	}
+	return d, nil
+}
+
+func parseIntervalClock(s string) (time.Duration, error) {
+	negative := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimLeft(s, "+-"), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid interval time %q", s)
+	}
+
+	hours, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval time %q: %w", s, err)
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval time %q: %w", s, err)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval time %q: %w", s, err)
+	}
+
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// Inet maps to inet, a host address with an optional netmask
+type Inet net.IPNet
+
+func (Inet) GormDataType() string {
+	return string(inetDataType)
+}
+
+func (i *Inet) Scan(value interface{}) error {
+	if value == nil {
+		*i = Inet{}
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan Inet value: %v", value)
+	}
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("invalid inet value %q", s)
+		}
+		bits := net.IPv6len * 8
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, net.IPv4len*8
+		}
+		*i = Inet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		return nil
+	}
+
+	ip, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return err
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	*i = Inet{IP: ip, Mask: network.Mask}
+	return nil
+}
+
+func (i Inet) Value() (driver.Value, error) {
+	if i.IP == nil {
+		return nil, nil
+	}
+	return i.String(), nil
+}
+
+func (i Inet) String() string {
+	return (*net.IPNet)(&i).String()
+}
+
+// CIDR maps to cidr, a network address
+type CIDR net.IPNet
+
+func (CIDR) GormDataType() string {
+	return string(cidrDataType)
+}
+
+func (c *CIDR) Scan(value interface{}) error {
+	if value == nil {
+		*c = CIDR{}
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan CIDR value: %v", value)
+	}
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return err
+	}
+	*c = CIDR(*network)
+	return nil
+}
+
+func (c CIDR) Value() (driver.Value, error) {
+	if c.IP == nil {
+		return nil, nil
+	}
+	return c.String(), nil
+}
+
+func (c CIDR) String() string {
+	return (*net.IPNet)(&c).String()
+}
+
+// MacAddr maps to macaddr
+type MacAddr net.HardwareAddr
+
+func (MacAddr) GormDataType() string {
+	return string(macAddrDataType)
+}
+
+func (m *MacAddr) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan MacAddr value: %v", value)
+	}
+	addr, err := net.ParseMAC(s)
+	if err != nil {
+		return err
+	}
+	*m = MacAddr(addr)
+	return nil
+}
+
+func (m MacAddr) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return m.String(), nil
+}
+
+func (m MacAddr) String() string {
+	return net.HardwareAddr(m).String()
+}
+
+// UUID maps to uuid
+type UUID [16]byte
+
+// ParseUUID parses the canonical, braced or unhyphenated textual form of an uuid
+func ParseUUID(s string) (u UUID, err error) {
+	s = strings.ReplaceAll(strings.Trim(s, "{}"), "-", "")
+	if len(s) != 32 {
+		return u, fmt.Errorf("invalid uuid %q", s)
+	}
+	if _, err = hex.Decode(u[:], []byte(s)); err != nil {
+		return u, fmt.Errorf("invalid uuid %q: %w", s, err)
+	}
+	return u, nil
+}
+
+func (UUID) GormDataType() string {
+	return string(uuidDataType)
+}
+
+func (u *UUID) Scan(value interface{}) (err error) {
+	switch v := value.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case []byte:
+		if len(v) == len(u) {
+			copy(u[:], v)
+			return nil
+		}
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan UUID value: %v", value)
+	}
+	*u, err = ParseUUID(s)
+	return err
+}
+
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+func (u UUID) String() string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf)
+}
+
+var errInvalidBitString = errors.New("bit string must only contain 0 and 1")
+
+func validBitString(s string) error {
+	if strings.Trim(s, "01") != "" {
+		return errInvalidBitString
+	}
+	return nil
+}
+
+// Bit maps to bit(n), n is the size tag of the field and defaults to 1
+type Bit string
+
+func (Bit) GormDataType() string {
+	return string(bitDataType)
+}
+
+func (b *Bit) Scan(value interface{}) error {
+	if value == nil {
+		*b = ""
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan Bit value: %v", value)
+	}
+	*b = Bit(s)
+	return nil
+}
+
+func (b Bit) Value() (driver.Value, error) {
+	if err := validBitString(string(b)); err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// VarBit maps to varbit, or varbit(n) with a size tag
+type VarBit string
+
+func (VarBit) GormDataType() string {
+	return string(varBitDataType)
+}
+
+func (b *VarBit) Scan(value interface{}) error {
+	if value == nil {
+		*b = ""
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan VarBit value: %v", value)
+	}
+	*b = VarBit(s)
+	return nil
+}
+
+func (b VarBit) Value() (driver.Value, error) {
+	if err := validBitString(string(b)); err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// XML maps to xml
+type XML string
+
+func (XML) GormDataType() string {
+	return string(xmlDataType)
+}
+
+func (x *XML) Scan(value interface{}) error {
+	if value == nil {
+		*x = ""
+		return nil
+	}
+
+	s, ok := asString(value)
+	if !ok {
+		return fmt.Errorf("failed to scan XML value: %v", value)
+	}
+	*x = XML(s)
+	return nil
+}
+
+func (x XML) Value() (driver.Value, error) {
+	if x == "" {
+		return nil, nil
+	}
+	return string(x), nil
+}